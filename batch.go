@@ -0,0 +1,42 @@
+package cachego
+
+import (
+	"time"
+)
+
+type batchCache struct {
+	Cache
+}
+
+// NewBatchCache returns a BatchCache for the given cache. When the cache
+// already implements BatchCache it is returned as is, otherwise the keys are
+// saved and deleted one by one.
+func NewBatchCache(cache Cache) BatchCache {
+	if c, ok := cache.(BatchCache); ok {
+		return c
+	}
+
+	return &batchCache{cache}
+}
+
+// DeleteMulti removes multiple cached keys, stopping at the first failure
+func (c *batchCache) DeleteMulti(keys []string) error {
+	for _, key := range keys {
+		if err := c.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SaveMulti caches multiple values by their keys, stopping at the first failure
+func (c *batchCache) SaveMulti(values map[string]string, lifeTime time.Duration) error {
+	for key, value := range values {
+		if err := c.Save(key, value, lifeTime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
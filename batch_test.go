@@ -0,0 +1,33 @@
+package cachego
+
+import (
+	"testing"
+)
+
+func TestBatchCache(t *testing.T) {
+	c := NewBatchCache(mapCache{})
+
+	if c != NewBatchCache(c) {
+		t.Error("batch cache failed: expected the same instance")
+	}
+
+	if err := c.SaveMulti(map[string]string{"foo": "bar", "baz": "qux"}, 0); err != nil {
+		t.Errorf("save multi failed: expected nil, got %v", err)
+	}
+
+	if values := c.FetchMulti([]string{"foo", "baz"}); len(values) != 2 || values["baz"] != "qux" {
+		t.Errorf("fetch multi failed: expected %d, got %v", 2, values)
+	}
+
+	if err := c.DeleteMulti([]string{"foo", "baz", "missing"}); err != nil {
+		t.Errorf("delete multi failed: expected nil, got %v", err)
+	}
+
+	if values := c.FetchMulti([]string{"foo", "baz"}); len(values) != 0 {
+		t.Errorf("delete multi failed: expected %d, got %v", 0, values)
+	}
+
+	if err := NewBatchCache(failingCache{mapCache{}}).SaveMulti(map[string]string{"foo": "bar"}, 0); err == nil {
+		t.Errorf("save multi failed: expected an error, got %v", err)
+	}
+}
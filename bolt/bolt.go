@@ -2,8 +2,14 @@
 package bolt
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"strconv"
 	"time"
 
 	bt "go.etcd.io/bbolt"
@@ -15,85 +21,282 @@ var boltBucket = []byte("cachego")
 
 type (
 	bolt struct {
-		db *bt.DB
+		db       *bt.DB
+		bucket   []byte
+		clock    cachego.Clock
+		interval time.Duration
+		janitor  *cachego.Janitor
 	}
 
+	// Option configures the BoltDB cache driver
+	Option func(*bolt)
+
+	// boltContent is stored as the content version, followed by the
+	// big-endian expiration, the big-endian stale time, both in Unix
+	// nanoseconds, and the raw data
 	boltContent struct {
+		duration int64
+		stale    int64
+		data     []byte
+
+		// record is the stored value, its digest is the version of the key
+		record []byte
+	}
+
+	// jsonContent is the content format written by the previous releases,
+	// keeping the expiration in Unix seconds
+	jsonContent struct {
 		Duration int64  `json:"duration"`
 		Data     string `json:"data,omitempty"`
 	}
 )
 
+const (
+	contentVersion = 1
+	contentHeader  = 17
+	staleOffset    = 9
+)
+
 // New creates an instance of BoltDB cache
-func New(db *bt.DB) cachego.Cache {
-	return &bolt{db}
+func New(db *bt.DB, opts ...Option) cachego.Cache {
+	b := &bolt{db: db, bucket: boltBucket, clock: cachego.SystemClock{}}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	if b.interval > 0 {
+		b.janitor = cachego.NewJanitor(b, b.interval)
+	}
+
+	return b
+}
+
+// WithClock sets the clock used to compute the expiration of the keys
+func WithClock(clock cachego.Clock) Option {
+	return func(b *bolt) {
+		b.clock = clock
+	}
 }
 
-func (b *bolt) read(key string) (*boltContent, error) {
+// WithJanitor purges the expired keys in the background every interval,
+// until the cache is closed
+func WithJanitor(interval time.Duration) Option {
+	return func(b *bolt) {
+		b.interval = interval
+	}
+}
+
+func (b *bolt) read(ctx context.Context, key string) (*boltContent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	var value []byte
 
 	err := b.db.View(func(tx *bt.Tx) error {
-		if bucket := tx.Bucket(boltBucket); bucket != nil {
-			value = bucket.Get([]byte(key))
-			return nil
+		if bucket := tx.Bucket(b.bucket); bucket != nil {
+			value = bytes.Clone(bucket.Get([]byte(key)))
 		}
 
-		return errors.New("bucket not found")
+		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	content := &boltContent{}
-	if err := json.Unmarshal(value, content); err != nil {
+	if value == nil {
+		return nil, cachego.ErrCacheMiss
+	}
+
+	content, err := decode(value)
+	if err != nil {
 		return nil, err
 	}
 
-	if content.Duration == 0 {
+	content.record = value
+
+	if content.duration == 0 {
 		return content, nil
 	}
 
-	if content.Duration <= time.Now().Unix() {
-		_ = b.Delete(key)
+	if content.duration <= b.clock.Now().UnixNano() {
+		_ = b.DeleteContext(ctx, key)
 		return nil, cachego.ErrCacheExpired
 	}
 
 	return content, nil
 }
 
+// digest returns the SHA-256 of the record in hex
+func digest(record []byte) string {
+	sum := sha256.Sum256(record)
+	return hex.EncodeToString(sum[:])
+}
+
+func encode(content *boltContent) []byte {
+	data := make([]byte, 0, contentHeader+len(content.data))
+	data = append(data, contentVersion)
+	data = binary.BigEndian.AppendUint64(data, uint64(content.duration))
+	data = binary.BigEndian.AppendUint64(data, uint64(content.stale))
+
+	return append(data, content.data...)
+}
+
+func decode(value []byte) (*boltContent, error) {
+	if len(value) > 0 && value[0] == '{' {
+		legacy := &jsonContent{}
+		if err := json.Unmarshal(value, legacy); err != nil {
+			return nil, err
+		}
+
+		return &boltContent{duration: fromSeconds(legacy.Duration), data: []byte(legacy.Data)}, nil
+	}
+
+	if len(value) < contentHeader || value[0] != contentVersion {
+		return nil, cachego.ErrDecode
+	}
+
+	return &boltContent{
+		duration: int64(binary.BigEndian.Uint64(value[1:staleOffset])),
+		stale:    int64(binary.BigEndian.Uint64(value[staleOffset:contentHeader])),
+		data:     value[contentHeader:],
+	}, nil
+}
+
+// fromSeconds converts the Unix seconds of the JSON content to Unix
+// nanoseconds, keeping zero as no time
+func fromSeconds(sec int64) int64 {
+	if sec <= 0 {
+		return sec
+	}
+
+	return time.Unix(sec, 0).UnixNano()
+}
+
+// Close stops the janitor of the BoltDB storage, the database is not closed
+func (b *bolt) Close() error {
+	if b.janitor != nil {
+		b.janitor.Stop()
+	}
+
+	return nil
+}
+
 // Contains checks if the cached key exists into the BoltDB storage
 func (b *bolt) Contains(key string) bool {
-	_, err := b.read(key)
+	return b.ContainsContext(context.Background(), key)
+}
+
+// ContainsContext checks if the cached key exists into the BoltDB storage
+func (b *bolt) ContainsContext(ctx context.Context, key string) bool {
+	_, err := b.read(ctx, key)
 	return err == nil
 }
 
 // Delete the cached key from BoltDB storage
 func (b *bolt) Delete(key string) error {
+	return b.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext the cached key from BoltDB storage
+func (b *bolt) DeleteContext(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return b.DeleteMulti([]string{key})
+}
+
+// DeleteMulti removes multiple cached keys from BoltDB storage in a single transaction
+func (b *bolt) DeleteMulti(keys []string) error {
 	return b.db.Update(func(tx *bt.Tx) error {
-		if bucket := tx.Bucket(boltBucket); bucket != nil {
-			return bucket.Delete([]byte(key))
+		bucket := tx.Bucket(b.bucket)
+		if bucket == nil {
+			return errors.New("bucket not found")
+		}
+
+		for _, key := range keys {
+			if err := bucket.Delete([]byte(key)); err != nil {
+				return err
+			}
 		}
 
-		return errors.New("bucket not found")
+		return nil
 	})
 }
 
 // Fetch retrieves the cached value from key of the BoltDB storage
 func (b *bolt) Fetch(key string) (string, error) {
-	content, err := b.read(key)
+	return b.FetchContext(context.Background(), key)
+}
+
+// FetchContext retrieves the cached value from key of the BoltDB storage
+func (b *bolt) FetchContext(ctx context.Context, key string) (string, error) {
+	content, err := b.read(ctx, key)
 	if err != nil {
 		return "", err
 	}
 
-	return content.Data, nil
+	return string(content.data), nil
+}
+
+// FetchBytes retrieves the cached binary value from key of the BoltDB storage
+func (b *bolt) FetchBytes(key string) ([]byte, error) {
+	content, err := b.read(context.Background(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	return content.data, nil
+}
+
+// FetchStale retrieves the cached value from key of the BoltDB storage and
+// whether it is stale
+func (b *bolt) FetchStale(key string) (string, bool, error) {
+	content, err := b.read(context.Background(), key)
+	if err != nil {
+		return "", false, err
+	}
+
+	return string(content.data), content.stale > 0 && content.stale <= b.clock.Now().UnixNano(), nil
+}
+
+// FetchWithVersion retrieves the cached value from key of the BoltDB storage
+// and the digest of its record as version
+func (b *bolt) FetchWithVersion(key string) (string, string, error) {
+	content, err := b.read(context.Background(), key)
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(content.data), digest(content.record), nil
 }
 
 // FetchMulti retrieve multiple cached values from keys of the BoltDB storage
 func (b *bolt) FetchMulti(keys []string) map[string]string {
+	return b.FetchMultiContext(context.Background(), keys)
+}
+
+// FetchMultiContext retrieve multiple cached values from keys of the BoltDB storage
+func (b *bolt) FetchMultiContext(ctx context.Context, keys []string) map[string]string {
 	result := make(map[string]string)
 
 	for _, key := range keys {
-		if value, err := b.Fetch(key); err == nil {
+		if value, err := b.FetchContext(ctx, key); err == nil {
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+// FetchMultiBytes retrieve multiple cached binary values from keys of the BoltDB storage
+func (b *bolt) FetchMultiBytes(keys []string) map[string][]byte {
+	result := make(map[string][]byte)
+
+	for _, key := range keys {
+		if value, err := b.FetchBytes(key); err == nil {
 			result[key] = value
 		}
 	}
@@ -103,32 +306,300 @@ func (b *bolt) FetchMulti(keys []string) map[string]string {
 
 // Flush removes all cached keys of the BoltDB storage
 func (b *bolt) Flush() error {
+	return b.FlushContext(context.Background())
+}
+
+// FlushContext removes all cached keys of the BoltDB storage
+func (b *bolt) FlushContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	return b.db.Update(func(tx *bt.Tx) error {
-		return tx.DeleteBucket(boltBucket)
+		return tx.DeleteBucket(b.bucket)
 	})
 }
 
+// Namespace returns a view of the BoltDB storage keeping the keys in a bucket
+// of its own, sharing the database without its janitor
+func (b *bolt) Namespace(prefix string) cachego.Cache {
+	bucket := append(bytes.Clone(b.bucket), ':')
+
+	return &bolt{db: b.db, bucket: append(bucket, prefix...), clock: b.clock}
+}
+
+// PurgeExpired removes the expired keys of the BoltDB storage
+func (b *bolt) PurgeExpired() (int, error) {
+	now := b.clock.Now().UnixNano()
+	purged := 0
+
+	err := b.db.Update(func(tx *bt.Tx) error {
+		bucket := tx.Bucket(b.bucket)
+		if bucket == nil {
+			return nil
+		}
+
+		var expired [][]byte
+
+		err := bucket.ForEach(func(key, value []byte) error {
+			content, err := decode(value)
+			if err == nil && content.duration > 0 && content.duration <= now {
+				expired = append(expired, bytes.Clone(key))
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, key := range expired {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+
+		purged = len(expired)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
 // Save a value in BoltDB storage by key
 func (b *bolt) Save(key string, value string, lifeTime time.Duration) error {
-	duration := int64(0)
+	return b.SaveContext(context.Background(), key, value, lifeTime)
+}
 
-	if lifeTime > 0 {
-		duration = time.Now().Unix() + int64(lifeTime.Seconds())
+// SaveContext a value in BoltDB storage by key
+func (b *bolt) SaveContext(ctx context.Context, key string, value string, lifeTime time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	content := &boltContent{duration, value}
+	return b.write(key, []byte(value), 0, lifeTime)
+}
 
-	data, err := json.Marshal(content)
-	if err != nil {
-		return err
+// SaveBytes a binary value in BoltDB storage by key
+func (b *bolt) SaveBytes(key string, value []byte, lifeTime time.Duration) error {
+	return b.write(key, value, 0, lifeTime)
+}
+
+// SaveStale a value in BoltDB storage by key that becomes stale after the stale time
+func (b *bolt) SaveStale(key string, value string, staleTime, lifeTime time.Duration) error {
+	return b.write(key, []byte(value), staleTime, lifeTime)
+}
+
+// SaveMulti multiple values in BoltDB storage by their keys in a single transaction
+func (b *bolt) SaveMulti(values map[string]string, lifeTime time.Duration) error {
+	records := make(map[string][]byte, len(values))
+
+	for key, value := range values {
+		records[key] = b.record([]byte(value), 0, lifeTime)
 	}
 
+	return b.put(records)
+}
+
+// SaveIfVersion a value in BoltDB storage by key while the digest of its
+// record is the version, checked and written in a single transaction
+func (b *bolt) SaveIfVersion(key, value, version string, lifeTime time.Duration) error {
+	record := b.record([]byte(value), 0, lifeTime)
+
+	return b.ifVersion(key, version, func(bucket *bt.Bucket) error {
+		return bucket.Put([]byte(key), record)
+	})
+}
+
+// DeleteIfVersion removes the cached key from BoltDB storage while the digest
+// of its record is the version, checked and removed in a single transaction
+func (b *bolt) DeleteIfVersion(key, version string) error {
+	if version == "" {
+		return cachego.ErrVersionMismatch
+	}
+
+	return b.ifVersion(key, version, func(bucket *bt.Bucket) error {
+		return bucket.Delete([]byte(key))
+	})
+}
+
+// ifVersion calls fn with the bucket while the digest of the record of the key
+// is the version, in a single transaction
+func (b *bolt) ifVersion(key, version string, fn func(*bt.Bucket) error) error {
+	now := b.clock.Now().UnixNano()
+
 	return b.db.Update(func(tx *bt.Tx) error {
-		bucket, err := tx.CreateBucketIfNotExists(boltBucket)
+		bucket, err := tx.CreateBucketIfNotExists(b.bucket)
 		if err != nil {
 			return err
 		}
 
-		return bucket.Put([]byte(key), data)
+		current := ""
+
+		// the expired keys are replaced as missing ones
+		if value := bucket.Get([]byte(key)); value != nil {
+			if content, err := decode(value); err != nil || content.duration == 0 || content.duration > now {
+				current = digest(value)
+			}
+		}
+
+		if current != version {
+			return cachego.ErrVersionMismatch
+		}
+
+		return fn(bucket)
+	})
+}
+
+// SaveIfAbsent a value in BoltDB storage by key only when the key is missing
+func (b *bolt) SaveIfAbsent(key string, value string, lifeTime time.Duration) (bool, error) {
+	saved := false
+
+	err := b.update(key, func(current *boltContent) ([]byte, error) {
+		if saved = current == nil; !saved {
+			return nil, nil
+		}
+
+		return b.record([]byte(value), 0, lifeTime), nil
+	})
+
+	return saved, err
+}
+
+// Replace a value in BoltDB storage by key only when the key exists
+func (b *bolt) Replace(key string, value string, lifeTime time.Duration) (bool, error) {
+	saved := false
+
+	err := b.update(key, func(current *boltContent) ([]byte, error) {
+		if saved = current != nil; !saved {
+			return nil, nil
+		}
+
+		return b.record([]byte(value), 0, lifeTime), nil
+	})
+
+	return saved, err
+}
+
+// Touch updates the life time of the cached key in BoltDB storage
+func (b *bolt) Touch(key string, lifeTime time.Duration) error {
+	return b.update(key, func(current *boltContent) ([]byte, error) {
+		if current == nil {
+			return nil, cachego.ErrCacheMiss
+		}
+
+		content := &boltContent{stale: current.stale, data: current.data}
+
+		if lifeTime > 0 {
+			content.duration = b.clock.Now().Add(lifeTime).UnixNano()
+		}
+
+		return encode(content), nil
+	})
+}
+
+// Incr increments the value of the cached key in BoltDB storage, keeping its
+// life time
+func (b *bolt) Incr(key string, delta int64) (int64, error) {
+	var value int64
+
+	err := b.update(key, func(current *boltContent) ([]byte, error) {
+		content := &boltContent{}
+
+		if current != nil {
+			n, err := strconv.ParseInt(string(current.data), 10, 64)
+			if err != nil {
+				return nil, cachego.ErrNotInteger
+			}
+
+			content.duration, content.stale, value = current.duration, current.stale, n
+		}
+
+		value += delta
+		content.data = []byte(strconv.FormatInt(value, 10))
+
+		return encode(content), nil
+	})
+
+	return value, err
+}
+
+// Decr decrements the value of the cached key in BoltDB storage, keeping its
+// life time
+func (b *bolt) Decr(key string, delta int64) (int64, error) {
+	return b.Incr(key, -delta)
+}
+
+// update writes the record returned by fn for the content of the key in a
+// single transaction, the content is nil for a missing or expired key and a
+// nil record leaves the key as it is
+func (b *bolt) update(key string, fn func(*boltContent) ([]byte, error)) error {
+	now := b.clock.Now().UnixNano()
+
+	return b.db.Update(func(tx *bt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(b.bucket)
+		if err != nil {
+			return err
+		}
+
+		var current *boltContent
+
+		if value := bucket.Get([]byte(key)); value != nil {
+			content, err := decode(value)
+			if err != nil {
+				return err
+			}
+
+			if content.duration == 0 || content.duration > now {
+				current = content
+			}
+		}
+
+		record, err := fn(current)
+		if err != nil || record == nil {
+			return err
+		}
+
+		return bucket.Put([]byte(key), record)
+	})
+}
+
+func (b *bolt) write(key string, value []byte, staleTime, lifeTime time.Duration) error {
+	return b.put(map[string][]byte{key: b.record(value, staleTime, lifeTime)})
+}
+
+// record returns the encoded value expiring after the life time
+func (b *bolt) record(value []byte, staleTime, lifeTime time.Duration) []byte {
+	now := b.clock.Now()
+	content := &boltContent{data: value}
+
+	if lifeTime > 0 {
+		content.duration = now.Add(lifeTime).UnixNano()
+	}
+
+	if staleTime > 0 {
+		content.stale = now.Add(staleTime).UnixNano()
+	}
+
+	return encode(content)
+}
+
+func (b *bolt) put(records map[string][]byte) error {
+	return b.db.Update(func(tx *bt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(b.bucket)
+		if err != nil {
+			return err
+		}
+
+		for key, data := range records {
+			if err := bucket.Put([]byte(key), data); err != nil {
+				return err
+			}
+		}
+
+		return nil
 	})
 }
@@ -1,12 +1,18 @@
 package bolt
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"testing"
 	"time"
 
 	bt "go.etcd.io/bbolt"
+
+	"github.com/faabiosr/cachego"
+	"github.com/faabiosr/cachego/cachegotest"
 )
 
 const (
@@ -74,3 +80,231 @@ func TestBolt(t *testing.T) {
 		t.Errorf("contains failed: the key %s should not be exist", testKey)
 	}
 }
+
+func TestBoltContext(t *testing.T) {
+	db, err := bt.Open(fmt.Sprintf("%s/cachego.db", t.TempDir()), 0o600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	c := New(db).(cachego.ContextCache)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if err := c.SaveContext(ctx, testKey, testValue, 0); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.FetchContext(ctx, testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	cancel()
+
+	if err := c.SaveContext(ctx, testKey, testValue, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("save failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if _, err := c.FetchContext(ctx, testKey); !errors.Is(err, context.Canceled) {
+		t.Errorf("fetch failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if err := c.DeleteContext(ctx, testKey); !errors.Is(err, context.Canceled) {
+		t.Errorf("delete failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if err := c.FlushContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("flush failed: expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestBoltBytes(t *testing.T) {
+	db, err := bt.Open(fmt.Sprintf("%s/cachego.db", t.TempDir()), 0o600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	c := New(db).(cachego.BytesCache)
+	value := []byte{0x00, 0xff, 0xfe, '"', '\\'}
+
+	if err := c.SaveBytes(testKey, value, 10*time.Second); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.FetchBytes(testKey); !bytes.Equal(res, value) {
+		t.Errorf("fetch fail, wrong value: expected %v, got %v", value, res)
+	}
+
+	if _, err := c.FetchBytes("bar"); err == nil {
+		t.Errorf("fetch fail: expected an error, got %v", err)
+	}
+
+	if values := c.FetchMultiBytes([]string{testKey, "bar"}); !bytes.Equal(values[testKey], value) {
+		t.Errorf("fetch multi failed, wrong value: expected %v, got %v", value, values[testKey])
+	}
+
+	err = db.Update(func(tx *bt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte(testKey), []byte(`{"duration":0,"data":"bar"}`))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+}
+
+func TestBoltCacheMiss(t *testing.T) {
+	db, err := bt.Open(fmt.Sprintf("%s/cachego.db", t.TempDir()), 0o600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	c := New(db)
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+
+	if _, err := c.(cachego.BytesCache).FetchBytes(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+
+	_ = c.Save(testKey, testValue, 1*time.Nanosecond)
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+}
+
+func TestBoltStale(t *testing.T) {
+	db, err := bt.Open(fmt.Sprintf("%s/cachego.db", t.TempDir()), 0o600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	c := New(db).(cachego.StaleCache)
+
+	if err := c.SaveStale(testKey, testValue, 1*time.Nanosecond, 10*time.Second); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, stale, _ := c.FetchStale(testKey); res != testValue || !stale {
+		t.Errorf("fetch stale fail: expected a stale %s, got %s (stale %v)", testValue, res, stale)
+	}
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	_ = c.SaveStale(testKey, testValue, 10*time.Second, 0)
+
+	if _, stale, _ := c.FetchStale(testKey); stale {
+		t.Errorf("fetch stale fail: the key %s should not be stale", testKey)
+	}
+
+	_ = c.SaveStale(testKey, testValue, 0, 1*time.Nanosecond)
+
+	if _, _, err := c.FetchStale(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+}
+
+func TestBoltSuite(t *testing.T) {
+	cachegotest.RunClockSuite(t, func(t *testing.T, clock cachego.Clock) cachego.Cache {
+		db, err := bt.Open(fmt.Sprintf("%s/cachego.db", t.TempDir()), 0o600, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(func() {
+			_ = db.Close()
+		})
+
+		return New(db, WithClock(clock))
+	})
+}
+
+func TestBoltMigration(t *testing.T) {
+	db, err := bt.Open(fmt.Sprintf("%s/cachego.db", t.TempDir()), 0o600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	clock := cachegotest.NewClock(time.Unix(1700000000, 0))
+	c := New(db, WithClock(clock))
+
+	err = db.Update(func(tx *bt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(boltBucket)
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put([]byte(testKey), []byte(`{"duration":1700000010,"data":"bar"}`))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(9 * time.Second)
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	clock.Advance(time.Second)
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheExpired) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheExpired, err)
+	}
+}
+
+func TestBoltSubSecond(t *testing.T) {
+	db, err := bt.Open(fmt.Sprintf("%s/cachego.db", t.TempDir()), 0o600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	clock := cachegotest.NewClock(time.Now())
+	c := New(db, WithClock(clock))
+
+	_ = c.Save(testKey, testValue, 500*time.Millisecond)
+
+	clock.Advance(499 * time.Millisecond)
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	clock.Advance(time.Millisecond)
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheExpired) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheExpired, err)
+	}
+}
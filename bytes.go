@@ -0,0 +1,46 @@
+package cachego
+
+import (
+	"time"
+)
+
+type bytesCache struct {
+	Cache
+}
+
+// NewBytesCache returns a BytesCache for the given cache. When the cache
+// already implements BytesCache it is returned as is, otherwise the binary
+// values are converted from and to string.
+func NewBytesCache(cache Cache) BytesCache {
+	if c, ok := cache.(BytesCache); ok {
+		return c
+	}
+
+	return &bytesCache{cache}
+}
+
+// FetchBytes retrieves the cached key binary value
+func (c *bytesCache) FetchBytes(key string) ([]byte, error) {
+	value, err := c.Fetch(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(value), nil
+}
+
+// FetchMultiBytes retrieves multiple cached keys binary value
+func (c *bytesCache) FetchMultiBytes(keys []string) map[string][]byte {
+	result := make(map[string][]byte)
+
+	for key, value := range c.FetchMulti(keys) {
+		result[key] = []byte(value)
+	}
+
+	return result
+}
+
+// SaveBytes caches a binary value by key
+func (c *bytesCache) SaveBytes(key string, value []byte, lifeTime time.Duration) error {
+	return c.Save(key, string(value), lifeTime)
+}
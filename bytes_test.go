@@ -0,0 +1,37 @@
+package cachego
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBytesCache(t *testing.T) {
+	c := NewBytesCache(mapCache{})
+
+	if c != NewBytesCache(c) {
+		t.Error("bytes cache failed: expected the same instance")
+	}
+
+	value := []byte{0x00, 0xff, 'f', 'o', 'o'}
+
+	if err := c.SaveBytes("foo", value, 0); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.FetchBytes("foo"); !bytes.Equal(res, value) {
+		t.Errorf("fetch fail, wrong value: expected %v, got %v", value, res)
+	}
+
+	if _, err := c.FetchBytes("bar"); err == nil {
+		t.Errorf("fetch fail: expected an error, got %v", err)
+	}
+
+	values := c.FetchMultiBytes([]string{"foo", "bar"})
+	if len(values) != 1 {
+		t.Errorf("fetch multi failed: expected %d, got %d", 1, len(values))
+	}
+
+	if !bytes.Equal(values["foo"], value) {
+		t.Errorf("fetch multi failed, wrong value: expected %v, got %v", value, values["foo"])
+	}
+}
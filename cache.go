@@ -1,6 +1,7 @@
 package cachego
 
 import (
+	"context"
 	"time"
 )
 
@@ -25,4 +26,133 @@ type (
 		// Save cache a value by key
 		Save(key string, value string, lifeTime time.Duration) error
 	}
+
+	// ContextCache is the context-aware cache interface, the context controls
+	// the deadline and cancellation of each operation
+	ContextCache interface {
+		Cache
+
+		// ContainsContext check if a cached key exists
+		ContainsContext(ctx context.Context, key string) bool
+
+		// DeleteContext remove the cached key
+		DeleteContext(ctx context.Context, key string) error
+
+		// FetchContext retrieve the cached key value
+		FetchContext(ctx context.Context, key string) (string, error)
+
+		// FetchMultiContext retrieve multiple cached keys value
+		FetchMultiContext(ctx context.Context, keys []string) map[string]string
+
+		// FlushContext remove all cached keys
+		FlushContext(ctx context.Context) error
+
+		// SaveContext cache a value by key
+		SaveContext(ctx context.Context, key string, value string, lifeTime time.Duration) error
+	}
+
+	// BytesCache is the cache interface for binary values, the values are
+	// stored as they are given without any encoding
+	BytesCache interface {
+		Cache
+
+		// FetchBytes retrieve the cached key binary value
+		FetchBytes(key string) ([]byte, error)
+
+		// FetchMultiBytes retrieve multiple cached keys binary value
+		FetchMultiBytes(keys []string) map[string][]byte
+
+		// SaveBytes cache a binary value by key
+		SaveBytes(key string, value []byte, lifeTime time.Duration) error
+	}
+
+	// StaleCache is the cache interface for values with a soft life time,
+	// once the stale time passes the value is still served, flagged as stale,
+	// until the life time removes it
+	StaleCache interface {
+		Cache
+
+		// FetchStale retrieve the cached key value and whether it is stale
+		FetchStale(key string) (string, bool, error)
+
+		// SaveStale cache a value by key that becomes stale after the stale time
+		SaveStale(key string, value string, staleTime, lifeTime time.Duration) error
+	}
+
+	// Purger is the cache interface for removing the expired keys at once,
+	// instead of waiting for them to be read
+	Purger interface {
+		Cache
+
+		// PurgeExpired remove the expired keys, returning how many were removed
+		PurgeExpired() (int, error)
+	}
+
+	// BatchCache is the cache interface for saving and deleting multiple keys
+	// at once, in a single round trip or transaction when the storage allows
+	BatchCache interface {
+		Cache
+
+		// DeleteMulti remove multiple cached keys
+		DeleteMulti(keys []string) error
+
+		// SaveMulti cache multiple values by their keys
+		SaveMulti(values map[string]string, lifeTime time.Duration) error
+	}
+
+	// CASCache is the cache interface for the compare-and-swap updates, the
+	// version of a key is an opaque token replaced when the key is written,
+	// so that concurrent read-modify-write of a key stop losing updates
+	CASCache interface {
+		Cache
+
+		// FetchWithVersion retrieve the cached key value and its version
+		FetchWithVersion(key string) (string, string, error)
+
+		// SaveIfVersion cache a value by key only while the key still has
+		// the version, an empty version only saves a missing key, otherwise
+		// it returns ErrVersionMismatch
+		SaveIfVersion(key, value, version string, lifeTime time.Duration) error
+
+		// DeleteIfVersion remove the cached key only while it still has the
+		// version, otherwise it returns ErrVersionMismatch
+		DeleteIfVersion(key, version string) error
+	}
+
+	// AtomicCache is the cache interface for the conditional writes and the
+	// counters, each operation is atomic in the storage so that counters and
+	// locks can be built on top of it
+	AtomicCache interface {
+		Cache
+
+		// SaveIfAbsent cache a value by key only when the key is missing,
+		// returning whether it was saved
+		SaveIfAbsent(key string, value string, lifeTime time.Duration) (bool, error)
+
+		// Replace cache a value by key only when the key exists, returning
+		// whether it was saved
+		Replace(key string, value string, lifeTime time.Duration) (bool, error)
+
+		// Touch updates the life time of the cached key, a zero life time
+		// never expires, it returns ErrCacheMiss when the key is missing
+		Touch(key string, lifeTime time.Duration) error
+
+		// Incr increments the integer value of the cached key by delta and
+		// returns it, a missing key starts at zero and never expires, it
+		// returns ErrNotInteger when the value is not an integer
+		Incr(key string, delta int64) (int64, error)
+
+		// Decr decrements the integer value of the cached key by delta and
+		// returns it, as Incr does
+		Decr(key string, delta int64) (int64, error)
+	}
+
+	// Namespacer is the cache interface for the drivers providing their own
+	// namespaced views, the keys and the Flush of a view are scoped to it
+	Namespacer interface {
+		Cache
+
+		// Namespace returns a view of the cache scoped to the prefix
+		Namespace(prefix string) Cache
+	}
 )
@@ -0,0 +1,42 @@
+package cachegotest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a fake cachego.Clock for tests, its time only changes when it is
+// advanced or set
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock creates an instance of Clock starting at now
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now returns the current time of the clock
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Advance moves the clock forward by d
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// Set changes the current time of the clock
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = now
+}
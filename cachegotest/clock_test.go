@@ -0,0 +1,30 @@
+package cachegotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/faabiosr/cachego"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	var c cachego.Clock = NewClock(start)
+
+	if now := c.Now(); !now.Equal(start) {
+		t.Errorf("now failed: expected %v, got %v", start, now)
+	}
+
+	c.(*Clock).Advance(1500 * time.Millisecond)
+
+	if now := c.Now(); !now.Equal(start.Add(1500 * time.Millisecond)) {
+		t.Errorf("advance failed: expected %v, got %v", start.Add(1500*time.Millisecond), now)
+	}
+
+	c.(*Clock).Set(start)
+
+	if now := c.Now(); !now.Equal(start) {
+		t.Errorf("set failed: expected %v, got %v", start, now)
+	}
+}
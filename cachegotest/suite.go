@@ -0,0 +1,894 @@
+// Package cachegotest provides a conformance suite for the cache drivers,
+// asserting the behavior expected by the cachego.Cache contract.
+package cachegotest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/faabiosr/cachego"
+)
+
+const (
+	testKey   = "cachegotest-foo"
+	testValue = "bar"
+
+	workers    = 8
+	iterations = 50
+)
+
+type (
+	// Factory creates the cache under test, each subtest uses a new instance
+	Factory func(t *testing.T) cachego.Cache
+
+	// ClockFactory creates the cache under test using the clock to compute
+	// the expiration of the keys, each subtest uses a new instance
+	ClockFactory func(t *testing.T, clock cachego.Clock) cachego.Cache
+
+	// setup creates the cache under test and the function waiting for the
+	// time to pass
+	setup func(t *testing.T) (cachego.Cache, func(time.Duration))
+)
+
+// RunSuite runs the conformance tests against the caches created by factory,
+// the optional interfaces implemented by the cache are also exercised. The
+// suite waits for the keys to expire, so it takes a few seconds to run.
+func RunSuite(t *testing.T, factory Factory) {
+	t.Helper()
+
+	run(t, func(t *testing.T) (cachego.Cache, func(time.Duration)) {
+		return factory(t), time.Sleep
+	})
+}
+
+// RunClockSuite runs the same conformance tests as RunSuite, the caches use a
+// fake clock that the suite advances instead of waiting for the keys to expire.
+// The expiration is also expected to keep the sub-second precision of the
+// life time.
+func RunClockSuite(t *testing.T, factory ClockFactory) {
+	t.Helper()
+
+	setup := func(t *testing.T) (cachego.Cache, func(time.Duration)) {
+		clock := NewClock(time.Now())
+		return factory(t, clock), clock.Advance
+	}
+
+	run(t, setup)
+
+	t.Run("Precision", func(t *testing.T) { testPrecision(t, setup) })
+}
+
+func run(t *testing.T, setup setup) {
+	t.Helper()
+
+	cache := func(t *testing.T) cachego.Cache {
+		c, _ := setup(t)
+		return c
+	}
+
+	t.Run("SaveFetch", func(t *testing.T) { testSaveFetch(t, cache(t)) })
+	t.Run("Expiration", func(t *testing.T) { testExpiration(t, setup) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, cache(t)) })
+	t.Run("FetchMulti", func(t *testing.T) { testFetchMulti(t, cache(t)) })
+	t.Run("Flush", func(t *testing.T) { testFlush(t, cache(t)) })
+	t.Run("CacheMiss", func(t *testing.T) { testCacheMiss(t, cache(t)) })
+	t.Run("Concurrency", func(t *testing.T) { testConcurrency(t, cache(t)) })
+	t.Run("Namespace", func(t *testing.T) { testNamespace(t, cache(t)) })
+
+	t.Run("Context", func(t *testing.T) {
+		c, ok := cache(t).(cachego.ContextCache)
+		if !ok {
+			t.Skip("the cache does not implement cachego.ContextCache")
+		}
+
+		testContext(t, c)
+	})
+
+	t.Run("Bytes", func(t *testing.T) {
+		c, ok := cache(t).(cachego.BytesCache)
+		if !ok {
+			t.Skip("the cache does not implement cachego.BytesCache")
+		}
+
+		testBytes(t, c)
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		c, ok := cache(t).(cachego.BatchCache)
+		if !ok {
+			t.Skip("the cache does not implement cachego.BatchCache")
+		}
+
+		testBatch(t, c)
+	})
+
+	t.Run("PurgeExpired", func(t *testing.T) {
+		c, wait := setup(t)
+
+		p, ok := c.(cachego.Purger)
+		if !ok {
+			t.Skip("the cache does not implement cachego.Purger")
+		}
+
+		testPurgeExpired(t, p, wait)
+	})
+
+	t.Run("Stale", func(t *testing.T) {
+		c, wait := setup(t)
+
+		sc, ok := c.(cachego.StaleCache)
+		if !ok {
+			t.Skip("the cache does not implement cachego.StaleCache")
+		}
+
+		testStale(t, sc, wait)
+	})
+
+	t.Run("CAS", func(t *testing.T) {
+		c, wait := setup(t)
+
+		cc, ok := c.(cachego.CASCache)
+		if !ok {
+			t.Skip("the cache does not implement cachego.CASCache")
+		}
+
+		testCAS(t, cc, wait)
+	})
+
+	t.Run("Atomic", func(t *testing.T) {
+		c, wait := setup(t)
+
+		ac, ok := c.(cachego.AtomicCache)
+		if !ok {
+			t.Skip("the cache does not implement cachego.AtomicCache")
+		}
+
+		testAtomic(t, ac, wait)
+	})
+}
+
+func testSaveFetch(t *testing.T, c cachego.Cache) {
+	if err := c.Save(testKey, testValue, 10*time.Second); err != nil {
+		t.Fatalf("save fail: expected nil, got %v", err)
+	}
+
+	if res, err := c.Fetch(testKey); err != nil || res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s (%v)", testValue, res, err)
+	}
+
+	if !c.Contains(testKey) {
+		t.Errorf("contains failed: the key %s should be exist", testKey)
+	}
+
+	_ = c.Save(testKey, "baz", 10*time.Second)
+
+	if res, _ := c.Fetch(testKey); res != "baz" {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", "baz", res)
+	}
+
+	_ = c.Save(testKey, "", 10*time.Second)
+
+	if res, err := c.Fetch(testKey); err != nil || res != "" {
+		t.Errorf("fetch fail, wrong value: expected an empty value, got %q (%v)", res, err)
+	}
+}
+
+func testExpiration(t *testing.T, setup setup) {
+	c, wait := setup(t)
+	expiring, forever, later := testKey+"-expiring", testKey+"-forever", testKey+"-later"
+
+	if err := c.Save(expiring, testValue, 1*time.Second); err != nil {
+		t.Fatalf("save fail: expected nil, got %v", err)
+	}
+
+	if err := c.Save(forever, testValue, 0); err != nil {
+		t.Fatalf("save fail: expected nil, got %v", err)
+	}
+
+	if err := c.Save(later, testValue, time.Hour); err != nil {
+		t.Fatalf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.Fetch(expiring); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	wait(2 * time.Second)
+
+	if _, err := c.Fetch(expiring); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+
+	if c.Contains(expiring) {
+		t.Errorf("contains failed: the key %s should be expired", expiring)
+	}
+
+	values := c.FetchMulti([]string{expiring, forever, later})
+
+	if _, ok := values[expiring]; ok || len(values) != 2 {
+		t.Errorf("fetch multi failed: expected only %s and %s, got %v", forever, later, values)
+	}
+}
+
+func testPrecision(t *testing.T, setup setup) {
+	c, wait := setup(t)
+
+	if err := c.Save(testKey, testValue, 1500*time.Millisecond); err != nil {
+		t.Fatalf("save fail: expected nil, got %v", err)
+	}
+
+	wait(time.Second)
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	wait(600 * time.Millisecond)
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v after the sub-second life time, got %v", cachego.ErrCacheMiss, err)
+	}
+}
+
+func testDelete(t *testing.T, c cachego.Cache) {
+	_ = c.Save(testKey, testValue, 0)
+
+	if err := c.Delete(testKey); err != nil {
+		t.Errorf("delete failed: expected nil, got %v", err)
+	}
+
+	if c.Contains(testKey) {
+		t.Errorf("contains failed: the key %s should not be exist", testKey)
+	}
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+}
+
+func testFetchMulti(t *testing.T, c cachego.Cache) {
+	missing := testKey + "-missing"
+	_ = c.Delete(missing)
+
+	_ = c.Save(testKey+"-1", "1", 0)
+	_ = c.Save(testKey+"-2", "2", 10*time.Second)
+
+	values := c.FetchMulti([]string{testKey + "-1", missing, testKey + "-2"})
+
+	if len(values) != 2 || values[testKey+"-1"] != "1" || values[testKey+"-2"] != "2" {
+		t.Errorf("fetch multi failed: expected the %d saved keys, got %v", 2, values)
+	}
+
+	if _, ok := values[missing]; ok {
+		t.Errorf("fetch multi failed: the key %s should not be returned", missing)
+	}
+
+	if values := c.FetchMulti([]string{missing}); len(values) != 0 {
+		t.Errorf("fetch multi failed: expected %d, got %d", 0, len(values))
+	}
+
+	if values := c.FetchMulti(nil); len(values) != 0 {
+		t.Errorf("fetch multi failed: expected %d, got %d", 0, len(values))
+	}
+}
+
+func testFlush(t *testing.T, c cachego.Cache) {
+	_ = c.Save(testKey+"-1", testValue, 0)
+	_ = c.Save(testKey+"-2", testValue, 10*time.Second)
+
+	if err := c.Flush(); err != nil {
+		t.Errorf("flush failed: expected nil, got %v", err)
+	}
+
+	if values := c.FetchMulti([]string{testKey + "-1", testKey + "-2"}); len(values) != 0 {
+		t.Errorf("flush failed: expected no keys, got %v", values)
+	}
+
+	if err := c.Save(testKey, testValue, 0); err != nil {
+		t.Errorf("save fail: expected nil after flush, got %v", err)
+	}
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+}
+
+func testCacheMiss(t *testing.T, c cachego.Cache) {
+	_ = c.Delete(testKey)
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+
+	if c.Contains(testKey) {
+		t.Errorf("contains failed: the key %s should not be exist", testKey)
+	}
+}
+
+func testConcurrency(t *testing.T, c cachego.Cache) {
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func(w int) {
+			defer wg.Done()
+
+			for i := 0; i < iterations; i++ {
+				key := fmt.Sprintf("%s-%d", testKey, (w+i)%workers)
+				value := fmt.Sprintf("%d-%d", w, i)
+
+				if err := c.Save(key, value, 10*time.Second); err != nil {
+					t.Errorf("save fail: expected nil, got %v", err)
+					return
+				}
+
+				if _, err := c.Fetch(key); err != nil && !errors.Is(err, cachego.ErrCacheMiss) {
+					t.Errorf("fetch failed: expected a value or %v, got %v", cachego.ErrCacheMiss, err)
+					return
+				}
+
+				_ = c.FetchMulti([]string{key, testKey})
+			}
+		}(w)
+	}
+
+	wg.Wait()
+
+	for w := 0; w < workers; w++ {
+		key := fmt.Sprintf("%s-%d", testKey, w)
+
+		if !c.Contains(key) {
+			t.Errorf("contains failed: the key %s should be exist", key)
+		}
+	}
+}
+
+func testBatch(t *testing.T, c cachego.BatchCache) {
+	keys := []string{testKey, testKey + "-2", testKey + "-missing"}
+	values := map[string]string{keys[0]: testValue, keys[1]: testValue + "-2"}
+
+	if err := c.SaveMulti(values, 10*time.Second); err != nil {
+		t.Fatalf("save multi fail: expected nil, got %v", err)
+	}
+
+	if res := c.FetchMulti(keys); len(res) != 2 || res[keys[0]] != testValue || res[keys[1]] != testValue+"-2" {
+		t.Errorf("fetch multi failed, wrong value: expected %v, got %v", values, res)
+	}
+
+	if err := c.DeleteMulti(keys); err != nil {
+		t.Errorf("delete multi failed: expected nil, got %v", err)
+	}
+
+	if res := c.FetchMulti(keys); len(res) != 0 {
+		t.Errorf("delete multi failed: expected %d, got %d", 0, len(res))
+	}
+
+	if err := c.SaveMulti(map[string]string{}, 0); err != nil {
+		t.Errorf("save multi fail: expected nil for no values, got %v", err)
+	}
+
+	if err := c.DeleteMulti(nil); err != nil {
+		t.Errorf("delete multi failed: expected nil for no keys, got %v", err)
+	}
+}
+
+func testNamespace(t *testing.T, c cachego.Cache) {
+	ns := cachego.Namespace(c, "cachegotest:ns")
+	other := cachego.Namespace(c, "cachegotest:other")
+	missing := testKey + "-missing"
+
+	_ = c.Save(testKey, "root", 0)
+	_ = other.Save(testKey, "other", 0)
+	_ = ns.Delete(missing)
+
+	if err := ns.Save(testKey, testValue, 0); err != nil {
+		t.Fatalf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := ns.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	if values := ns.FetchMulti([]string{testKey, missing}); len(values) != 1 || values[testKey] != testValue {
+		t.Errorf("fetch multi failed, wrong value: expected %s, got %v", testValue, values)
+	}
+
+	if err := ns.Flush(); err != nil {
+		t.Errorf("flush failed: expected nil, got %v", err)
+	}
+
+	if ns.Contains(testKey) {
+		t.Errorf("contains failed: the key %s should not be exist", testKey)
+	}
+
+	if res, _ := c.Fetch(testKey); res != "root" {
+		t.Errorf("flush failed: expected the key out of the namespace %s, got %s", "root", res)
+	}
+
+	if res, _ := other.Fetch(testKey); res != "other" {
+		t.Errorf("flush failed: expected the key of another namespace %s, got %s", "other", res)
+	}
+
+	if err := ns.Save(testKey, testValue, 0); err != nil {
+		t.Errorf("save fail: expected nil after flush, got %v", err)
+	}
+
+	if res, _ := ns.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	testNamespacePrefix(t, c)
+	testNamespaceFirstSave(t, c)
+}
+
+// testNamespacePrefix flushes the namespaces whose prefix starts the names
+// of another namespace, of a nested namespace and of a key out of them
+func testNamespacePrefix(t *testing.T, c cachego.Cache) {
+	a := cachego.Namespace(c, "cachegotest:a")
+	ab := cachego.Namespace(c, "cachegotest:ab")
+	nested := cachego.Namespace(a, "b")
+	root := "cachegotest:abc"
+
+	_ = c.Save(root, testValue, 0)
+	_ = a.Save(testKey, testValue, 0)
+	_ = ab.Save(testKey, testValue, 0)
+
+	if err := nested.Save(testKey, testValue, 0); err != nil {
+		t.Fatalf("save fail: expected nil, got %v", err)
+	}
+
+	if err := ab.Flush(); err != nil {
+		t.Errorf("flush failed: expected nil, got %v", err)
+	}
+
+	if ab.Contains(testKey) {
+		t.Errorf("contains failed: the key %s should not be exist", testKey)
+	}
+
+	for name, ns := range map[string]cachego.Cache{"a": a, "nested": nested} {
+		if res, _ := ns.Fetch(testKey); res != testValue {
+			t.Errorf("flush failed: expected the key of the %s namespace %s, got %s", name, testValue, res)
+		}
+	}
+
+	if err := a.Flush(); err != nil {
+		t.Errorf("flush failed: expected nil, got %v", err)
+	}
+
+	if a.Contains(testKey) {
+		t.Errorf("contains failed: the key %s should not be exist", testKey)
+	}
+
+	if res, _ := c.Fetch(root); res != testValue {
+		t.Errorf("flush failed: expected the key out of the namespaces %s, got %s", testValue, res)
+	}
+
+	_ = c.Delete(root)
+	_ = nested.Flush()
+}
+
+// testNamespaceFirstSave saves the first keys of an empty namespace from
+// several workers, none of them may be lost by creating the namespace twice
+func testNamespaceFirstSave(t *testing.T, c cachego.Cache) {
+	views := map[string]func(prefix string) cachego.Cache{
+		"native": func(prefix string) cachego.Cache {
+			return cachego.Namespace(c, prefix)
+		},
+		"generation": func(prefix string) cachego.Cache {
+			return cachego.Namespace(struct{ cachego.Cache }{c}, prefix)
+		},
+	}
+
+	if ac, ok := c.(cachego.AtomicCache); ok {
+		views["atomic"] = func(prefix string) cachego.Cache {
+			return cachego.Namespace(struct{ cachego.AtomicCache }{ac}, prefix)
+		}
+	}
+
+	for name, view := range views {
+		ns := view("cachegotest:first:" + name)
+
+		for i := 0; i < iterations; i++ {
+			_ = ns.Flush()
+
+			var wg sync.WaitGroup
+
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+
+				go func(w int) {
+					defer wg.Done()
+
+					if err := view("cachegotest:first:"+name).Save(strconv.Itoa(w), testValue, 0); err != nil {
+						t.Errorf("save fail: expected nil, got %v", err)
+					}
+				}(w)
+			}
+
+			wg.Wait()
+
+			for w := 0; w < workers; w++ {
+				if res, _ := ns.Fetch(strconv.Itoa(w)); res != testValue {
+					t.Fatalf("fetch fail, wrong value of the %s view: expected %s, got %s", name, testValue, res)
+				}
+			}
+		}
+	}
+}
+
+func testContext(t *testing.T, c cachego.ContextCache) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if err := c.SaveContext(ctx, testKey, testValue, 0); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.FetchContext(ctx, testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	cancel()
+
+	if err := c.SaveContext(ctx, testKey, testValue, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("save failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if _, err := c.FetchContext(ctx, testKey); !errors.Is(err, context.Canceled) {
+		t.Errorf("fetch failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if c.ContainsContext(ctx, testKey) {
+		t.Errorf("contains failed: expected false with a canceled context")
+	}
+
+	if values := c.FetchMultiContext(ctx, []string{testKey}); len(values) != 0 {
+		t.Errorf("fetch multi failed: expected %d, got %d", 0, len(values))
+	}
+
+	if err := c.DeleteContext(ctx, testKey); !errors.Is(err, context.Canceled) {
+		t.Errorf("delete failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if err := c.FlushContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("flush failed: expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func testBytes(t *testing.T, c cachego.BytesCache) {
+	value := []byte{0x00, 0xff, 0xfe, '"', '\\', '{'}
+
+	if err := c.SaveBytes(testKey, value, 10*time.Second); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.FetchBytes(testKey); !bytes.Equal(res, value) {
+		t.Errorf("fetch fail, wrong value: expected %v, got %v", value, res)
+	}
+
+	missing := testKey + "-missing"
+	_ = c.Delete(missing)
+
+	if _, err := c.FetchBytes(missing); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+
+	values := c.FetchMultiBytes([]string{testKey, missing})
+
+	if len(values) != 1 || !bytes.Equal(values[testKey], value) {
+		t.Errorf("fetch multi failed, wrong value: expected %v, got %v", value, values[testKey])
+	}
+}
+
+func testPurgeExpired(t *testing.T, c cachego.Purger, wait func(time.Duration)) {
+	expiring, forever, later := testKey+"-expiring", testKey+"-forever", testKey+"-later"
+
+	_ = c.Save(expiring, testValue, 1*time.Second)
+	_ = c.Save(forever, testValue, 0)
+	_ = c.Save(later, testValue, time.Hour)
+
+	wait(2 * time.Second)
+
+	if purged, err := c.PurgeExpired(); err != nil || purged < 1 {
+		t.Errorf("purge expired failed: expected at least %d purged, got %d (%v)", 1, purged, err)
+	}
+
+	if !c.Contains(forever) || !c.Contains(later) {
+		t.Errorf("purge expired failed: the keys %s and %s should be exist", forever, later)
+	}
+
+	if purged, err := c.PurgeExpired(); err != nil || purged != 0 {
+		t.Errorf("purge expired failed: expected %d purged, got %d (%v)", 0, purged, err)
+	}
+}
+
+func testStale(t *testing.T, c cachego.StaleCache, wait func(time.Duration)) {
+	if err := c.SaveStale(testKey, testValue, 1*time.Nanosecond, 10*time.Second); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	wait(time.Millisecond)
+
+	if res, stale, _ := c.FetchStale(testKey); res != testValue || !stale {
+		t.Errorf("fetch stale fail: expected a stale %s, got %s (stale %v)", testValue, res, stale)
+	}
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	_ = c.SaveStale(testKey, testValue, time.Hour, 0)
+
+	if _, stale, _ := c.FetchStale(testKey); stale {
+		t.Errorf("fetch stale fail: the key %s should not be stale", testKey)
+	}
+}
+
+func testCAS(t *testing.T, c cachego.CASCache, wait func(time.Duration)) {
+	// the keys are expected missing, even on a storage kept between runs
+	_ = c.Flush()
+
+	if _, _, err := c.FetchWithVersion(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+
+	if err := c.SaveIfVersion(testKey, testValue, "", 0); err != nil {
+		t.Fatalf("save fail: expected nil for a missing key, got %v", err)
+	}
+
+	if err := c.SaveIfVersion(testKey, testValue, "", 0); !errors.Is(err, cachego.ErrVersionMismatch) {
+		t.Errorf("save failed: expected %v for an existing key, got %v", cachego.ErrVersionMismatch, err)
+	}
+
+	res, version, err := c.FetchWithVersion(testKey)
+	if err != nil || res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s (%v)", testValue, res, err)
+	}
+
+	if err := c.SaveIfVersion(testKey, "baz", version, 0); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if err := c.SaveIfVersion(testKey, "qux", version, 0); !errors.Is(err, cachego.ErrVersionMismatch) {
+		t.Errorf("save failed: expected %v for a replaced version, got %v", cachego.ErrVersionMismatch, err)
+	}
+
+	if res, _ := c.Fetch(testKey); res != "baz" {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", "baz", res)
+	}
+
+	_, version, _ = c.FetchWithVersion(testKey)
+	_ = c.Save(testKey, "qux", 0)
+
+	if err := c.SaveIfVersion(testKey, testValue, version, 0); !errors.Is(err, cachego.ErrVersionMismatch) {
+		t.Errorf("save failed: expected %v after a save, got %v", cachego.ErrVersionMismatch, err)
+	}
+
+	if err := c.SaveIfVersion(testKey+"-missing", testValue, version, 0); !errors.Is(err, cachego.ErrVersionMismatch) {
+		t.Errorf("save failed: expected %v for a missing key, got %v", cachego.ErrVersionMismatch, err)
+	}
+
+	expiring := testKey + "-expiring"
+	_ = c.Save(expiring, testValue, time.Second)
+
+	wait(2 * time.Second)
+
+	if err := c.SaveIfVersion(expiring, testValue, "", 0); err != nil {
+		t.Errorf("save fail: expected nil for an expired key, got %v", err)
+	}
+
+	testCASDelete(t, c)
+	testCASConcurrency(t, c)
+}
+
+func testCASDelete(t *testing.T, c cachego.CASCache) {
+	_ = c.Save(testKey, testValue, 0)
+	_, version, _ := c.FetchWithVersion(testKey)
+	_ = c.Save(testKey, "baz", 0)
+
+	if err := c.DeleteIfVersion(testKey, version); !errors.Is(err, cachego.ErrVersionMismatch) {
+		t.Errorf("delete failed: expected %v for a replaced version, got %v", cachego.ErrVersionMismatch, err)
+	}
+
+	if err := c.DeleteIfVersion(testKey, ""); !errors.Is(err, cachego.ErrVersionMismatch) {
+		t.Errorf("delete failed: expected %v for an empty version, got %v", cachego.ErrVersionMismatch, err)
+	}
+
+	_, version, _ = c.FetchWithVersion(testKey)
+
+	if err := c.DeleteIfVersion(testKey, version); err != nil {
+		t.Errorf("delete failed: expected nil, got %v", err)
+	}
+
+	if c.Contains(testKey) {
+		t.Errorf("delete failed: the key %s should not be exist", testKey)
+	}
+
+	if err := c.DeleteIfVersion(testKey, version); !errors.Is(err, cachego.ErrVersionMismatch) {
+		t.Errorf("delete failed: expected %v for a missing key, got %v", cachego.ErrVersionMismatch, err)
+	}
+}
+
+// testCASConcurrency increments a counter by read-modify-write from several
+// workers, retrying on version mismatch, no increment may be lost
+func testCASConcurrency(t *testing.T, c cachego.CASCache) {
+	counter := testKey + "-counter"
+	_ = c.Save(counter, "0", 0)
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < iterations; i++ {
+				if err := increment(c, counter); err != nil {
+					t.Errorf("increment failed: expected nil, got %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if res, _ := c.Fetch(counter); res != strconv.Itoa(workers*iterations) {
+		t.Errorf("increment failed, lost updates: expected %d, got %s", workers*iterations, res)
+	}
+}
+
+func increment(c cachego.CASCache, key string) error {
+	for {
+		value, version, err := c.FetchWithVersion(key)
+		if err != nil {
+			return err
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+
+		err = c.SaveIfVersion(key, strconv.Itoa(n+1), version, 0)
+		if !errors.Is(err, cachego.ErrVersionMismatch) {
+			return err
+		}
+	}
+}
+
+func testAtomic(t *testing.T, c cachego.AtomicCache, wait func(time.Duration)) {
+	// the keys are expected missing, even on a storage kept between runs
+	_ = c.Flush()
+
+	if ok, err := c.SaveIfAbsent(testKey, testValue, 0); !ok || err != nil {
+		t.Errorf("save failed: expected the missing key to be saved, got %v (%v)", ok, err)
+	}
+
+	if ok, err := c.SaveIfAbsent(testKey, "baz", 0); ok || err != nil {
+		t.Errorf("save failed: expected the existing key not to be saved, got %v (%v)", ok, err)
+	}
+
+	if ok, err := c.Replace(testKey, "baz", 0); !ok || err != nil {
+		t.Errorf("replace failed: expected the existing key to be saved, got %v (%v)", ok, err)
+	}
+
+	if res, _ := c.Fetch(testKey); res != "baz" {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", "baz", res)
+	}
+
+	missing := testKey + "-missing"
+
+	if ok, err := c.Replace(missing, testValue, 0); ok || err != nil {
+		t.Errorf("replace failed: expected the missing key not to be saved, got %v (%v)", ok, err)
+	}
+
+	if c.Contains(missing) {
+		t.Errorf("replace failed: the key %s should not be exist", missing)
+	}
+
+	if err := c.Touch(missing, time.Second); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("touch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+
+	testAtomicTouch(t, c, wait)
+	testAtomicCounter(t, c, wait)
+}
+
+func testAtomicTouch(t *testing.T, c cachego.AtomicCache, wait func(time.Duration)) {
+	kept, touched := testKey+"-kept", testKey+"-touched"
+
+	_ = c.Save(kept, testValue, time.Second)
+	_ = c.Save(touched, testValue, 0)
+
+	if err := c.Touch(kept, 0); err != nil {
+		t.Errorf("touch failed: expected nil, got %v", err)
+	}
+
+	if err := c.Touch(touched, time.Second); err != nil {
+		t.Errorf("touch failed: expected nil, got %v", err)
+	}
+
+	wait(2 * time.Second)
+
+	if res, _ := c.Fetch(kept); res != testValue {
+		t.Errorf("touch failed, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	if c.Contains(touched) {
+		t.Errorf("touch failed: the key %s should be expired", touched)
+	}
+
+	if ok, err := c.SaveIfAbsent(touched, testValue, 0); !ok || err != nil {
+		t.Errorf("save failed: expected the expired key to be saved, got %v (%v)", ok, err)
+	}
+}
+
+func testAtomicCounter(t *testing.T, c cachego.AtomicCache, wait func(time.Duration)) {
+	counter := testKey + "-counter"
+
+	if n, err := c.Incr(counter, 5); n != 5 || err != nil {
+		t.Errorf("incr failed, wrong value: expected %d, got %d (%v)", 5, n, err)
+	}
+
+	if n, err := c.Incr(counter, 3); n != 8 || err != nil {
+		t.Errorf("incr failed, wrong value: expected %d, got %d (%v)", 8, n, err)
+	}
+
+	if n, err := c.Decr(counter, 2); n != 6 || err != nil {
+		t.Errorf("decr failed, wrong value: expected %d, got %d (%v)", 6, n, err)
+	}
+
+	if res, _ := c.Fetch(counter); res != "6" {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", "6", res)
+	}
+
+	if _, err := c.Incr(testKey, 1); !errors.Is(err, cachego.ErrNotInteger) {
+		t.Errorf("incr failed: expected %v, got %v", cachego.ErrNotInteger, err)
+	}
+
+	expiring := testKey + "-expiring"
+	_ = c.Save(expiring, "1", time.Second)
+
+	if n, _ := c.Incr(expiring, 1); n != 2 {
+		t.Errorf("incr failed, wrong value: expected %d, got %d", 2, n)
+	}
+
+	wait(2 * time.Second)
+
+	if c.Contains(expiring) {
+		t.Errorf("incr failed: the key %s should keep its life time", expiring)
+	}
+
+	concurrent := testKey + "-concurrent"
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < iterations; i++ {
+				if _, err := c.Incr(concurrent, 1); err != nil {
+					t.Errorf("incr failed: expected nil, got %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if res, _ := c.Fetch(concurrent); res != strconv.Itoa(workers*iterations) {
+		t.Errorf("incr failed, lost updates: expected %d, got %s", workers*iterations, res)
+	}
+}
@@ -3,6 +3,7 @@
 package chain
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -22,8 +23,13 @@ func New(drivers ...cachego.Cache) cachego.Cache {
 
 // Contains checks if the cached key exists in one of the cache storages
 func (c *chain) Contains(key string) bool {
+	return c.ContainsContext(context.Background(), key)
+}
+
+// ContainsContext checks if the cached key exists in one of the cache storages
+func (c *chain) ContainsContext(ctx context.Context, key string) bool {
 	for _, driver := range c.drivers {
-		if driver.Contains(key) {
+		if cachego.NewContextCache(driver).ContainsContext(ctx, key) {
 			return true
 		}
 	}
@@ -33,8 +39,24 @@ func (c *chain) Contains(key string) bool {
 
 // Delete the cached key in all cache storages
 func (c *chain) Delete(key string) error {
+	return c.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext the cached key in all cache storages
+func (c *chain) DeleteContext(ctx context.Context, key string) error {
+	for _, driver := range c.drivers {
+		if err := cachego.NewContextCache(driver).DeleteContext(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteMulti removes multiple cached keys in all cache storages
+func (c *chain) DeleteMulti(keys []string) error {
 	for _, driver := range c.drivers {
-		if err := driver.Delete(key); err != nil {
+		if err := cachego.NewBatchCache(driver).DeleteMulti(keys); err != nil {
 			return err
 		}
 	}
@@ -44,23 +66,97 @@ func (c *chain) Delete(key string) error {
 
 // Fetch retrieves the value of one of the registred cache storages
 func (c *chain) Fetch(key string) (string, error) {
+	return c.FetchContext(context.Background(), key)
+}
+
+// FetchContext retrieves the value of one of the registred cache storages,
+// when none of them has the key the first failure other than a cache miss
+// is returned, or ErrCacheMiss otherwise. The values saved by SaveStale are
+// returned without their stale envelope.
+func (c *chain) FetchContext(ctx context.Context, key string) (string, error) {
+	failure := error(cachego.ErrCacheMiss)
+
 	for _, driver := range c.drivers {
-		value, err := driver.Fetch(key)
+		value, err := cachego.NewContextCache(cachego.NewStaleCache(driver)).FetchContext(ctx, key)
 
 		if err == nil {
 			return value, nil
 		}
+
+		failure = c.failure(failure, err)
 	}
 
-	return "", errors.New("key not found in cache chain")
+	return "", failure
+}
+
+// FetchBytes retrieves the binary value of one of the registred cache storages
+func (c *chain) FetchBytes(key string) ([]byte, error) {
+	failure := error(cachego.ErrCacheMiss)
+
+	for _, driver := range c.drivers {
+		value, err := cachego.NewBytesCache(cachego.NewStaleCache(driver)).FetchBytes(key)
+
+		if err == nil {
+			return value, nil
+		}
+
+		failure = c.failure(failure, err)
+	}
+
+	return nil, failure
+}
+
+// FetchStale retrieves the value of one of the registred cache storages and
+// whether it is stale
+func (c *chain) FetchStale(key string) (string, bool, error) {
+	failure := error(cachego.ErrCacheMiss)
+
+	for _, driver := range c.drivers {
+		value, stale, err := cachego.NewStaleCache(driver).FetchStale(key)
+
+		if err == nil {
+			return value, stale, nil
+		}
+
+		failure = c.failure(failure, err)
+	}
+
+	return "", false, failure
+}
+
+// failure keeps the first error other than a cache miss
+func (c *chain) failure(failure, err error) error {
+	if failure == cachego.ErrCacheMiss && !errors.Is(err, cachego.ErrCacheMiss) {
+		return err
+	}
+
+	return failure
 }
 
 // FetchMulti retrieves multiple cached values from one of the registred cache storages
 func (c *chain) FetchMulti(keys []string) map[string]string {
+	return c.FetchMultiContext(context.Background(), keys)
+}
+
+// FetchMultiContext retrieves multiple cached values from one of the registred cache storages
+func (c *chain) FetchMultiContext(ctx context.Context, keys []string) map[string]string {
 	result := make(map[string]string)
 
 	for _, key := range keys {
-		if value, err := c.Fetch(key); err == nil {
+		if value, err := c.FetchContext(ctx, key); err == nil {
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+// FetchMultiBytes retrieves multiple cached binary values from one of the registred cache storages
+func (c *chain) FetchMultiBytes(keys []string) map[string][]byte {
+	result := make(map[string][]byte)
+
+	for _, key := range keys {
+		if value, err := c.FetchBytes(key); err == nil {
 			result[key] = value
 		}
 	}
@@ -70,8 +166,13 @@ func (c *chain) FetchMulti(keys []string) map[string]string {
 
 // Flush removes all cached keys of the registered cache storages
 func (c *chain) Flush() error {
+	return c.FlushContext(context.Background())
+}
+
+// FlushContext removes all cached keys of the registered cache storages
+func (c *chain) FlushContext(ctx context.Context) error {
 	for _, driver := range c.drivers {
-		if err := driver.Flush(); err != nil {
+		if err := cachego.NewContextCache(driver).FlushContext(ctx); err != nil {
 			return err
 		}
 	}
@@ -79,10 +180,59 @@ func (c *chain) Flush() error {
 	return nil
 }
 
+// Namespace returns a chain of the views of the cache storages scoped to the prefix
+func (c *chain) Namespace(prefix string) cachego.Cache {
+	drivers := make([]cachego.Cache, len(c.drivers))
+
+	for i, driver := range c.drivers {
+		drivers[i] = cachego.Namespace(driver, prefix)
+	}
+
+	return &chain{drivers}
+}
+
 // Save a value in all cache storages by key
 func (c *chain) Save(key string, value string, lifeTime time.Duration) error {
+	return c.SaveContext(context.Background(), key, value, lifeTime)
+}
+
+// SaveContext a value in all cache storages by key
+func (c *chain) SaveContext(ctx context.Context, key string, value string, lifeTime time.Duration) error {
+	for _, driver := range c.drivers {
+		if err := cachego.NewContextCache(driver).SaveContext(ctx, key, value, lifeTime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SaveMulti multiple values in all cache storages by their keys
+func (c *chain) SaveMulti(values map[string]string, lifeTime time.Duration) error {
+	for _, driver := range c.drivers {
+		if err := cachego.NewBatchCache(driver).SaveMulti(values, lifeTime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SaveBytes a binary value in all cache storages by key
+func (c *chain) SaveBytes(key string, value []byte, lifeTime time.Duration) error {
+	for _, driver := range c.drivers {
+		if err := cachego.NewBytesCache(driver).SaveBytes(key, value, lifeTime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SaveStale a value in all cache storages by key that becomes stale after the stale time
+func (c *chain) SaveStale(key string, value string, staleTime, lifeTime time.Duration) error {
 	for _, driver := range c.drivers {
-		if err := driver.Save(key, value, lifeTime); err != nil {
+		if err := cachego.NewStaleCache(driver).SaveStale(key, value, staleTime, lifeTime); err != nil {
 			return err
 		}
 	}
@@ -1,10 +1,15 @@
 package chain
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/faabiosr/cachego"
+	"github.com/faabiosr/cachego/cachegotest"
 	"github.com/faabiosr/cachego/memcached"
 	"github.com/faabiosr/cachego/sync"
 )
@@ -72,3 +77,136 @@ func TestChain(t *testing.T) {
 		t.Errorf("flush failed: expected an error, got %v", err)
 	}
 }
+
+func TestChainContext(t *testing.T) {
+	c := New(sync.New(), sync.New()).(cachego.ContextCache)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if err := c.SaveContext(ctx, testKey, testValue, 0); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.FetchContext(ctx, testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	cancel()
+
+	if err := c.SaveContext(ctx, testKey, testValue, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("save failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if _, err := c.FetchContext(ctx, testKey); !errors.Is(err, context.Canceled) {
+		t.Errorf("fetch failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if c.ContainsContext(ctx, testKey) {
+		t.Errorf("contains failed: expected false with a canceled context")
+	}
+
+	if err := c.DeleteContext(ctx, testKey); !errors.Is(err, context.Canceled) {
+		t.Errorf("delete failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if err := c.FlushContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("flush failed: expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestChainBytes(t *testing.T) {
+	c := New(sync.New(), sync.New()).(cachego.BytesCache)
+	value := []byte{0x00, 0xff, 0xfe, '"', '\\'}
+
+	if err := c.SaveBytes(testKey, value, 0); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.FetchBytes(testKey); !bytes.Equal(res, value) {
+		t.Errorf("fetch fail, wrong value: expected %v, got %v", value, res)
+	}
+
+	if _, err := c.FetchBytes("bar"); err == nil {
+		t.Errorf("fetch fail: expected an error, got %v", err)
+	}
+
+	if values := c.FetchMultiBytes([]string{testKey, "bar"}); !bytes.Equal(values[testKey], value) {
+		t.Errorf("fetch multi failed, wrong value: expected %v, got %v", value, values[testKey])
+	}
+}
+
+func TestChainCacheMiss(t *testing.T) {
+	c := New(sync.New(), sync.New())
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+
+	if _, err := c.(cachego.BytesCache).FetchBytes(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+
+	c = New(
+		sync.New(),
+		memcached.New(memcache.New("127.0.0.1:22222")),
+	)
+
+	if _, err := c.Fetch(testKey); err == nil || errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected a failure other than %v, got %v", cachego.ErrCacheMiss, err)
+	}
+}
+
+func TestChainStale(t *testing.T) {
+	c := New(sync.New(), sync.New()).(cachego.StaleCache)
+
+	if err := c.SaveStale(testKey, testValue, 1*time.Nanosecond, 10*time.Second); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, stale, _ := c.FetchStale(testKey); res != testValue || !stale {
+		t.Errorf("fetch stale fail: expected a stale %s, got %s (stale %v)", testValue, res, stale)
+	}
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	_ = c.SaveStale(testKey, testValue, 10*time.Second, 0)
+
+	if _, stale, _ := c.FetchStale(testKey); stale {
+		t.Errorf("fetch stale fail: the key %s should not be stale", testKey)
+	}
+
+	_ = c.SaveStale(testKey, testValue, 0, 1*time.Nanosecond)
+
+	if _, _, err := c.FetchStale(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+}
+
+func TestChainStaleEnvelope(t *testing.T) {
+	// the drivers not storing the stale time keep it in an envelope
+	c := New(struct{ cachego.Cache }{sync.New()}, sync.New())
+
+	if err := c.(cachego.StaleCache).SaveStale(testKey, testValue, 10*time.Second, 0); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %q", testValue, res)
+	}
+
+	if values := c.FetchMulti([]string{testKey}); values[testKey] != testValue {
+		t.Errorf("fetch multi failed, wrong value: expected %s, got %q", testValue, values[testKey])
+	}
+
+	if res, _ := c.(cachego.BytesCache).FetchBytes(testKey); string(res) != testValue {
+		t.Errorf("fetch bytes fail, wrong value: expected %s, got %q", testValue, res)
+	}
+}
+
+func TestChainSuite(t *testing.T) {
+	cachegotest.RunClockSuite(t, func(_ *testing.T, clock cachego.Clock) cachego.Cache {
+		return New(sync.New(sync.WithClock(clock)), sync.New(sync.WithClock(clock)))
+	})
+}
@@ -0,0 +1,22 @@
+package cachego
+
+import (
+	"time"
+)
+
+type (
+	// Clock provides the current time used to compute the expiration of
+	// the cached keys
+	Clock interface {
+		// Now returns the current time
+		Now() time.Time
+	}
+
+	// SystemClock is the Clock reading the system time
+	SystemClock struct{}
+)
+
+// Now returns the current system time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,15 @@
+package cachego
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSystemClock(t *testing.T) {
+	before := time.Now()
+	now := SystemClock{}.Now()
+
+	if now.Before(before) || now.After(time.Now()) {
+		t.Errorf("now failed: expected the system time, got %v", now)
+	}
+}
@@ -0,0 +1,50 @@
+package cachego
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+type (
+	// Codec converts the values from and to their cached representation
+	Codec interface {
+		// Marshal returns the encoded value
+		Marshal(v any) ([]byte, error)
+
+		// Unmarshal decodes the data into the value pointed by v
+		Unmarshal(data []byte, v any) error
+	}
+
+	// JSONCodec encodes the values using encoding/json
+	JSONCodec struct{}
+
+	// GobCodec encodes the values using encoding/gob
+	GobCodec struct{}
+)
+
+// Marshal returns the JSON encoding of v
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes the JSON data into v
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// Marshal returns the gob encoding of v
+func (GobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes the gob data into v
+func (GobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
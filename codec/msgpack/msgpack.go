@@ -0,0 +1,19 @@
+// Package msgpack provides a codec that encodes the cached values using MessagePack.
+package msgpack
+
+import (
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec encodes the values using MessagePack
+type Codec struct{}
+
+// Marshal returns the MessagePack encoding of v
+func (Codec) Marshal(v any) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+// Unmarshal decodes the MessagePack data into v
+func (Codec) Unmarshal(data []byte, v any) error {
+	return msgpack.Unmarshal(data, v)
+}
@@ -0,0 +1,33 @@
+package msgpack
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/faabiosr/cachego"
+	"github.com/faabiosr/cachego/sync"
+)
+
+type testValue struct {
+	Name  string
+	Count int
+}
+
+func TestCodec(t *testing.T) {
+	c := cachego.NewTyped[testValue](sync.New(), Codec{})
+	expect := testValue{"foo", 2}
+
+	if err := c.Set("foo", expect, 0); err != nil {
+		t.Errorf("set fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.Get("foo"); res != expect {
+		t.Errorf("get fail, wrong value: expected %v, got %v", expect, res)
+	}
+
+	invalid := cachego.NewTyped[chan int](sync.New(), Codec{})
+
+	if err := invalid.Set("foo", make(chan int), 0); !errors.Is(err, cachego.ErrEncode) {
+		t.Errorf("set fail: expected %v, got %v", cachego.ErrEncode, err)
+	}
+}
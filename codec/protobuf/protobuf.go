@@ -0,0 +1,46 @@
+// Package protobuf provides a codec that encodes the cached values using Protocol Buffers.
+package protobuf
+
+import (
+	"fmt"
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec encodes the values using Protocol Buffers, the values must implement proto.Message
+type Codec struct{}
+
+// Marshal returns the Protocol Buffers encoding of v
+func (Codec) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("protobuf: %T does not implement proto.Message", v)
+	}
+
+	return proto.Marshal(msg)
+}
+
+// Unmarshal decodes the Protocol Buffers data into v, when v points to a nil
+// message a new one is allocated
+func (Codec) Unmarshal(data []byte, v any) error {
+	if msg, ok := v.(proto.Message); ok {
+		return proto.Unmarshal(data, msg)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Pointer {
+		return fmt.Errorf("protobuf: %T does not point to a proto.Message", v)
+	}
+
+	if rv.Elem().IsNil() {
+		rv.Elem().Set(reflect.New(rv.Elem().Type().Elem()))
+	}
+
+	msg, ok := rv.Elem().Interface().(proto.Message)
+	if !ok {
+		return fmt.Errorf("protobuf: %T does not point to a proto.Message", v)
+	}
+
+	return proto.Unmarshal(data, msg)
+}
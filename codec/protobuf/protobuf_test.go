@@ -0,0 +1,40 @@
+package protobuf
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/faabiosr/cachego"
+	"github.com/faabiosr/cachego/sync"
+)
+
+func TestCodec(t *testing.T) {
+	c := cachego.NewTyped[*wrapperspb.StringValue](sync.New(), Codec{})
+
+	if err := c.Set("foo", wrapperspb.String("bar"), 0); err != nil {
+		t.Errorf("set fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.Get("foo"); res.GetValue() != "bar" {
+		t.Errorf("get fail, wrong value: expected %s, got %s", "bar", res.GetValue())
+	}
+
+	value := &wrapperspb.StringValue{}
+	data, _ := Codec{}.Marshal(wrapperspb.String("bar"))
+
+	if err := (Codec{}).Unmarshal(data, value); err != nil || value.GetValue() != "bar" {
+		t.Errorf("unmarshal fail: expected %s, got %s (%v)", "bar", value.GetValue(), err)
+	}
+
+	invalid := cachego.NewTyped[string](sync.New(), Codec{})
+
+	if err := invalid.Set("foo", "bar", 0); !errors.Is(err, cachego.ErrEncode) {
+		t.Errorf("set fail: expected %v, got %v", cachego.ErrEncode, err)
+	}
+
+	if err := (Codec{}).Unmarshal(data, new(string)); err == nil {
+		t.Errorf("unmarshal fail: expected an error, got %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package cachego
+
+import (
+	"testing"
+)
+
+type codecValue struct {
+	Name  string
+	Count int
+}
+
+func TestCodec(t *testing.T) {
+	codecs := map[string]Codec{
+		"json": JSONCodec{},
+		"gob":  GobCodec{},
+	}
+
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			expect := codecValue{"foo", 2}
+
+			data, err := codec.Marshal(expect)
+			if err != nil {
+				t.Fatalf("marshal failed: expected nil, got %v", err)
+			}
+
+			var value codecValue
+
+			if err := codec.Unmarshal(data, &value); err != nil {
+				t.Errorf("unmarshal failed: expected nil, got %v", err)
+			}
+
+			if value != expect {
+				t.Errorf("unmarshal failed, wrong value: expected %v, got %v", expect, value)
+			}
+
+			if err := codec.Unmarshal([]byte("\x00"), &value); err == nil {
+				t.Errorf("unmarshal failed: expected an error, got %v", err)
+			}
+		})
+	}
+}
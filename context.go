@@ -0,0 +1,75 @@
+package cachego
+
+import (
+	"context"
+	"time"
+)
+
+type contextCache struct {
+	Cache
+}
+
+// NewContextCache returns a ContextCache for the given cache. When the cache
+// already implements ContextCache it is returned as is, otherwise the context
+// is checked before each operation is delegated to the cache.
+func NewContextCache(cache Cache) ContextCache {
+	if c, ok := cache.(ContextCache); ok {
+		return c
+	}
+
+	return &contextCache{cache}
+}
+
+// ContainsContext checks if the cached key exists
+func (c *contextCache) ContainsContext(ctx context.Context, key string) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	return c.Contains(key)
+}
+
+// DeleteContext removes the cached key
+func (c *contextCache) DeleteContext(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return c.Delete(key)
+}
+
+// FetchContext retrieves the cached key value
+func (c *contextCache) FetchContext(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	return c.Fetch(key)
+}
+
+// FetchMultiContext retrieves multiple cached keys value
+func (c *contextCache) FetchMultiContext(ctx context.Context, keys []string) map[string]string {
+	if ctx.Err() != nil {
+		return make(map[string]string)
+	}
+
+	return c.FetchMulti(keys)
+}
+
+// FlushContext removes all cached keys
+func (c *contextCache) FlushContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return c.Flush()
+}
+
+// SaveContext caches a value by key
+func (c *contextCache) SaveContext(ctx context.Context, key string, value string, lifeTime time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return c.Save(key, value, lifeTime)
+}
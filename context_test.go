@@ -0,0 +1,106 @@
+package cachego
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type mapCache map[string]string
+
+func (m mapCache) Contains(key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+func (m mapCache) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+func (m mapCache) Fetch(key string) (string, error) {
+	if v, ok := m[key]; ok {
+		return v, nil
+	}
+
+	return "", ErrCacheMiss
+}
+
+func (m mapCache) FetchMulti(keys []string) map[string]string {
+	result := make(map[string]string)
+
+	for _, key := range keys {
+		if v, ok := m[key]; ok {
+			result[key] = v
+		}
+	}
+
+	return result
+}
+
+func (m mapCache) Flush() error {
+	for key := range m {
+		delete(m, key)
+	}
+
+	return nil
+}
+
+func (m mapCache) Save(key string, value string, _ time.Duration) error {
+	m[key] = value
+	return nil
+}
+
+func TestContextCache(t *testing.T) {
+	c := NewContextCache(mapCache{})
+
+	if c != NewContextCache(c) {
+		t.Error("context cache failed: expected the same instance")
+	}
+
+	ctx := context.Background()
+
+	if err := c.SaveContext(ctx, "foo", "bar", 0); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.FetchContext(ctx, "foo"); res != "bar" {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", "bar", res)
+	}
+
+	if !c.ContainsContext(ctx, "foo") {
+		t.Errorf("contains failed: the key %s should be exist", "foo")
+	}
+
+	if values := c.FetchMultiContext(ctx, []string{"foo"}); len(values) != 1 {
+		t.Errorf("fetch multi failed: expected %d, got %d", 1, len(values))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if err := c.SaveContext(ctx, "foo", "bar", 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("save failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if _, err := c.FetchContext(ctx, "foo"); !errors.Is(err, context.Canceled) {
+		t.Errorf("fetch failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if c.ContainsContext(ctx, "foo") {
+		t.Errorf("contains failed: expected false with a canceled context")
+	}
+
+	if values := c.FetchMultiContext(ctx, []string{"foo"}); len(values) != 0 {
+		t.Errorf("fetch multi failed: expected %d, got %d", 0, len(values))
+	}
+
+	if err := c.DeleteContext(ctx, "foo"); !errors.Is(err, context.Canceled) {
+		t.Errorf("delete failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if err := c.FlushContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("flush failed: expected %v, got %v", context.Canceled, err)
+	}
+}
@@ -7,7 +7,16 @@ func (e err) Error() string {
 	return string(e)
 }
 
+// Is reports whether the error matches the target, an expired cache key is
+// also a cache miss.
+func (e err) Is(target error) bool {
+	return e == ErrCacheExpired && target == ErrCacheMiss
+}
+
 const (
+	// ErrCacheMiss returns an error when the cache key was not found.
+	ErrCacheMiss = err("cache miss")
+
 	// ErrCacheExpired returns an error when the cache key was expired.
 	ErrCacheExpired = err("cache expired")
 
@@ -22,4 +31,15 @@ const (
 
 	// ErrDecode returns an errors when decode fails.
 	ErrDecode = err("unable to decode")
+
+	// ErrEncode returns an error when encode fails.
+	ErrEncode = err("unable to encode")
+
+	// ErrVersionMismatch returns an error when the version of the cache key
+	// changed since it was fetched.
+	ErrVersionMismatch = err("version mismatch")
+
+	// ErrNotInteger returns an error when the value of the cache key is not
+	// an integer.
+	ErrNotInteger = err("value is not an integer")
 )
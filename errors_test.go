@@ -1,6 +1,7 @@
 package cachego
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 )
@@ -13,3 +14,21 @@ func TestError(t *testing.T) {
 		t.Errorf("invalid string: expect %s, got %s", expect, r)
 	}
 }
+
+func TestErrorIs(t *testing.T) {
+	if !errors.Is(ErrCacheExpired, ErrCacheMiss) {
+		t.Errorf("is failed: %v should match %v", ErrCacheExpired, ErrCacheMiss)
+	}
+
+	if !errors.Is(fmt.Errorf("wrapped: %w", ErrCacheExpired), ErrCacheMiss) {
+		t.Errorf("is failed: wrapped %v should match %v", ErrCacheExpired, ErrCacheMiss)
+	}
+
+	if errors.Is(ErrCacheMiss, ErrCacheExpired) {
+		t.Errorf("is failed: %v should not match %v", ErrCacheMiss, ErrCacheExpired)
+	}
+
+	if errors.Is(ErrSave, ErrCacheMiss) {
+		t.Errorf("is failed: %v should not match %v", ErrSave, ErrCacheMiss)
+	}
+}
@@ -2,9 +2,12 @@
 package file
 
 import (
+	"context"
 	"crypto/sha256"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -16,21 +19,70 @@ import (
 
 type (
 	file struct {
-		dir string
+		dir      string
+		clock    cachego.Clock
+		interval time.Duration
+		janitor  *cachego.Janitor
 		sync.RWMutex
 	}
 
+	// Option configures the File cache driver
+	Option func(*file)
+
+	// fileContent is stored as the content version, followed by the
+	// big-endian expiration, the big-endian stale time, both in Unix
+	// nanoseconds, and the raw data
 	fileContent struct {
+		duration int64
+		stale    int64
+		data     []byte
+	}
+
+	// jsonContent is the content format written by the previous releases,
+	// keeping the expiration in Unix seconds
+	jsonContent struct {
 		Duration int64  `json:"duration"`
 		Data     string `json:"data,omitempty"`
 	}
 )
 
-const perm = 0o666
+const (
+	perm    = 0o666
+	dirPerm = 0o777
+
+	contentVersion = 1
+	contentHeader  = 17
+	staleOffset    = 9
+)
 
 // New creates an instance of File cache
-func New(dir string) cachego.Cache {
-	return &file{dir: dir}
+func New(dir string, opts ...Option) cachego.Cache {
+	f := &file{dir: dir, clock: cachego.SystemClock{}}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	if f.interval > 0 {
+		f.janitor = cachego.NewJanitor(f, f.interval)
+	}
+
+	return f
+}
+
+// WithClock sets the clock used to compute the expiration of the keys
+func WithClock(clock cachego.Clock) Option {
+	return func(f *file) {
+		f.clock = clock
+	}
+}
+
+// WithJanitor purges the expired keys in the background every interval,
+// until the cache is closed
+func WithJanitor(interval time.Duration) Option {
+	return func(f *file) {
+		f.interval = interval
+	}
 }
 
 func (f *file) createName(key string) string {
@@ -46,31 +98,84 @@ func (f *file) read(key string) (*fileContent, error) {
 	defer f.RUnlock()
 
 	value, err := os.ReadFile(f.createName(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, cachego.ErrCacheMiss
+	}
+
 	if err != nil {
 		return nil, err
 	}
 
-	content := &fileContent{}
-	if err := json.Unmarshal(value, content); err != nil {
-		return nil, err
+	return decode(value)
+}
+
+func encode(content *fileContent) []byte {
+	data := make([]byte, 0, contentHeader+len(content.data))
+	data = append(data, contentVersion)
+	data = binary.BigEndian.AppendUint64(data, uint64(content.duration))
+	data = binary.BigEndian.AppendUint64(data, uint64(content.stale))
+
+	return append(data, content.data...)
+}
+
+func decode(value []byte) (*fileContent, error) {
+	if len(value) > 0 && value[0] == '{' {
+		legacy := &jsonContent{}
+		if err := json.Unmarshal(value, legacy); err != nil {
+			return nil, err
+		}
+
+		return &fileContent{duration: fromSeconds(legacy.Duration), data: []byte(legacy.Data)}, nil
+	}
+
+	if len(value) < contentHeader || value[0] != contentVersion {
+		return nil, cachego.ErrDecode
 	}
 
-	if content.Duration == 0 {
-		return content, nil
+	return &fileContent{
+		duration: int64(binary.BigEndian.Uint64(value[1:staleOffset])),
+		stale:    int64(binary.BigEndian.Uint64(value[staleOffset:contentHeader])),
+		data:     value[contentHeader:],
+	}, nil
+}
+
+// fromSeconds converts the Unix seconds of the JSON content to Unix
+// nanoseconds, keeping zero as no time
+func fromSeconds(sec int64) int64 {
+	if sec <= 0 {
+		return sec
 	}
 
-	return content, nil
+	return time.Unix(sec, 0).UnixNano()
+}
+
+// Close stops the janitor of the File storage
+func (f *file) Close() error {
+	if f.janitor != nil {
+		f.janitor.Stop()
+	}
+
+	return nil
 }
 
 // Contains checks if the cached key exists into the File storage
 func (f *file) Contains(key string) bool {
+	return f.ContainsContext(context.Background(), key)
+}
+
+// ContainsContext checks if the cached key exists into the File storage
+func (f *file) ContainsContext(ctx context.Context, key string) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
 	content, err := f.read(key)
 	if err != nil {
 		return false
 	}
 
 	if f.isExpired(content) {
-		_ = f.Delete(key)
+		_ = f.DeleteContext(ctx, key)
 		return false
 	}
 	return true
@@ -78,6 +183,15 @@ func (f *file) Contains(key string) bool {
 
 // Delete the cached key from File storage
 func (f *file) Delete(key string) error {
+	return f.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext the cached key from File storage
+func (f *file) DeleteContext(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	f.Lock()
 	defer f.Unlock()
 
@@ -91,29 +205,87 @@ func (f *file) Delete(key string) error {
 
 // Fetch retrieves the cached value from key of the File storage
 func (f *file) Fetch(key string) (string, error) {
+	return f.FetchContext(context.Background(), key)
+}
+
+// FetchContext retrieves the cached value from key of the File storage
+func (f *file) FetchContext(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
 	content, err := f.read(key)
 	if err != nil {
 		return "", err
 	}
 
 	if f.isExpired(content) {
-		_ = f.Delete(key)
+		_ = f.DeleteContext(ctx, key)
 		return "", cachego.ErrCacheExpired
 	}
 
-	return content.Data, nil
+	return string(content.data), nil
+}
+
+// FetchBytes retrieves the cached binary value from key of the File storage
+func (f *file) FetchBytes(key string) ([]byte, error) {
+	content, err := f.read(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.isExpired(content) {
+		_ = f.Delete(key)
+		return nil, cachego.ErrCacheExpired
+	}
+
+	return content.data, nil
+}
+
+// FetchStale retrieves the cached value from key of the File storage and
+// whether it is stale
+func (f *file) FetchStale(key string) (string, bool, error) {
+	content, err := f.read(key)
+	if err != nil {
+		return "", false, err
+	}
+
+	if f.isExpired(content) {
+		_ = f.Delete(key)
+		return "", false, cachego.ErrCacheExpired
+	}
+
+	return string(content.data), content.stale > 0 && content.stale <= f.clock.Now().UnixNano(), nil
 }
 
 func (f *file) isExpired(content *fileContent) bool {
-	return content.Duration > 0 && content.Duration <= time.Now().Unix()
+	return content.duration > 0 && content.duration <= f.clock.Now().UnixNano()
 }
 
 // FetchMulti retrieve multiple cached values from keys of the File storage
 func (f *file) FetchMulti(keys []string) map[string]string {
+	return f.FetchMultiContext(context.Background(), keys)
+}
+
+// FetchMultiContext retrieve multiple cached values from keys of the File storage
+func (f *file) FetchMultiContext(ctx context.Context, keys []string) map[string]string {
 	result := make(map[string]string)
 
 	for _, key := range keys {
-		if value, err := f.Fetch(key); err == nil {
+		if value, err := f.FetchContext(ctx, key); err == nil {
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+// FetchMultiBytes retrieve multiple cached binary values from keys of the File storage
+func (f *file) FetchMultiBytes(keys []string) map[string][]byte {
+	result := make(map[string][]byte)
+
+	for _, key := range keys {
+		if value, err := f.FetchBytes(key); err == nil {
 			result[key] = value
 		}
 	}
@@ -123,6 +295,15 @@ func (f *file) FetchMulti(keys []string) map[string]string {
 
 // Flush removes all cached keys of the File storage
 func (f *file) Flush() error {
+	return f.FlushContext(context.Background())
+}
+
+// FlushContext removes all cached keys of the File storage
+func (f *file) FlushContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	f.Lock()
 	defer f.Unlock()
 
@@ -135,30 +316,112 @@ func (f *file) Flush() error {
 		_ = dir.Close()
 	}()
 
-	names, _ := dir.Readdirnames(-1)
+	entries, _ := dir.ReadDir(-1)
+
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// the directories keep the keys of the namespaces
+		if entry.IsDir() {
+			continue
+		}
 
-	for _, name := range names {
-		_ = os.Remove(filepath.Join(f.dir, name))
+		_ = os.Remove(filepath.Join(f.dir, entry.Name()))
 	}
 
 	return nil
 }
 
+// Namespace returns a view of the File storage keeping the keys in a
+// subdirectory of its own, without the janitor
+func (f *file) Namespace(prefix string) cachego.Cache {
+	h := sha256.Sum256([]byte(prefix))
+	dir := filepath.Join(f.dir, hex.EncodeToString(h[:]))
+
+	// a directory that can not be created fails the writes of the view
+	_ = os.MkdirAll(dir, dirPerm)
+
+	return &file{dir: dir, clock: f.clock}
+}
+
+// PurgeExpired removes the expired keys of the File storage
+func (f *file) PurgeExpired() (int, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return 0, err
+	}
+
+	now := f.clock.Now().UnixNano()
+	purged := 0
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".cachego" {
+			continue
+		}
+
+		name := filepath.Join(f.dir, entry.Name())
+
+		value, err := os.ReadFile(name)
+		if err != nil {
+			continue
+		}
+
+		content, err := decode(value)
+		if err != nil || content.duration == 0 || content.duration > now {
+			continue
+		}
+
+		if err := os.Remove(name); err == nil {
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
 // Save a value in File storage by key
 func (f *file) Save(key string, value string, lifeTime time.Duration) error {
+	return f.SaveContext(context.Background(), key, value, lifeTime)
+}
+
+// SaveContext a value in File storage by key
+func (f *file) SaveContext(ctx context.Context, key string, value string, lifeTime time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return f.write(key, []byte(value), 0, lifeTime)
+}
+
+// SaveBytes a binary value in File storage by key
+func (f *file) SaveBytes(key string, value []byte, lifeTime time.Duration) error {
+	return f.write(key, value, 0, lifeTime)
+}
+
+// SaveStale a value in File storage by key that becomes stale after the stale time
+func (f *file) SaveStale(key string, value string, staleTime, lifeTime time.Duration) error {
+	return f.write(key, []byte(value), staleTime, lifeTime)
+}
+
+func (f *file) write(key string, value []byte, staleTime, lifeTime time.Duration) error {
 	f.Lock()
 	defer f.Unlock()
 
-	duration := int64(0)
+	now := f.clock.Now()
+	content := &fileContent{data: value}
+
 	if lifeTime > 0 {
-		duration = time.Now().Unix() + int64(lifeTime.Seconds())
+		content.duration = now.Add(lifeTime).UnixNano()
 	}
 
-	content := &fileContent{duration, value}
-	data, err := json.Marshal(content)
-	if err != nil {
-		return err
+	if staleTime > 0 {
+		content.stale = now.Add(staleTime).UnixNano()
 	}
 
-	return os.WriteFile(f.createName(key), data, perm)
+	return os.WriteFile(f.createName(key), encode(content), perm)
 }
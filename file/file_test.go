@@ -1,9 +1,15 @@
 package file
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"os"
 	"testing"
 	"time"
+
+	"github.com/faabiosr/cachego"
+	"github.com/faabiosr/cachego/cachegotest"
 )
 
 const (
@@ -67,3 +73,194 @@ func TestFile(t *testing.T) {
 		t.Errorf("flush failed: expected an error, got %v", err)
 	}
 }
+
+func TestFileContext(t *testing.T) {
+	c := New(t.TempDir()).(cachego.ContextCache)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if err := c.SaveContext(ctx, testKey, testValue, 0); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.FetchContext(ctx, testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	cancel()
+
+	if err := c.SaveContext(ctx, testKey, testValue, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("save failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if _, err := c.FetchContext(ctx, testKey); !errors.Is(err, context.Canceled) {
+		t.Errorf("fetch failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if c.ContainsContext(ctx, testKey) {
+		t.Errorf("contains failed: expected false with a canceled context")
+	}
+
+	if err := c.DeleteContext(ctx, testKey); !errors.Is(err, context.Canceled) {
+		t.Errorf("delete failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if err := c.FlushContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("flush failed: expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestFileBytes(t *testing.T) {
+	c := New(t.TempDir()).(cachego.BytesCache)
+	value := []byte{0x00, 0xff, 0xfe, '"', '\\'}
+
+	if err := c.SaveBytes(testKey, value, 10*time.Second); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.FetchBytes(testKey); !bytes.Equal(res, value) {
+		t.Errorf("fetch fail, wrong value: expected %v, got %v", value, res)
+	}
+
+	if _, err := c.FetchBytes("bar"); err == nil {
+		t.Errorf("fetch fail: expected an error, got %v", err)
+	}
+
+	if values := c.FetchMultiBytes([]string{testKey, "bar"}); !bytes.Equal(values[testKey], value) {
+		t.Errorf("fetch multi failed, wrong value: expected %v, got %v", value, values[testKey])
+	}
+}
+
+func TestFileLegacyContent(t *testing.T) {
+	f := New(t.TempDir()).(*file)
+
+	if err := os.WriteFile(f.createName(testKey), []byte(`{"duration":0,"data":"bar"}`), perm); err != nil {
+		t.Fatal(err)
+	}
+
+	if res, _ := f.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	if err := os.WriteFile(f.createName(testKey), []byte{contentVersion}, perm); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.Fetch(testKey); !errors.Is(err, cachego.ErrDecode) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrDecode, err)
+	}
+}
+
+func TestFileCacheMiss(t *testing.T) {
+	c := New(t.TempDir())
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+
+	if _, err := c.(cachego.BytesCache).FetchBytes(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+
+	_ = c.Save(testKey, testValue, 1*time.Nanosecond)
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+}
+
+func TestFileStale(t *testing.T) {
+	c := New(t.TempDir()).(cachego.StaleCache)
+
+	if err := c.SaveStale(testKey, testValue, 1*time.Nanosecond, 10*time.Second); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, stale, _ := c.FetchStale(testKey); res != testValue || !stale {
+		t.Errorf("fetch stale fail: expected a stale %s, got %s (stale %v)", testValue, res, stale)
+	}
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	_ = c.SaveStale(testKey, testValue, 10*time.Second, 0)
+
+	if _, stale, _ := c.FetchStale(testKey); stale {
+		t.Errorf("fetch stale fail: the key %s should not be stale", testKey)
+	}
+
+	_ = c.SaveStale(testKey, testValue, 0, 1*time.Nanosecond)
+
+	if _, _, err := c.FetchStale(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+}
+
+func TestFileSuite(t *testing.T) {
+	cachegotest.RunClockSuite(t, func(t *testing.T, clock cachego.Clock) cachego.Cache {
+		return New(t.TempDir(), WithClock(clock))
+	})
+}
+
+func TestFileMigration(t *testing.T) {
+	clock := cachegotest.NewClock(time.Unix(1700000000, 0))
+	f := New(t.TempDir(), WithClock(clock)).(*file)
+
+	if err := os.WriteFile(f.createName(testKey), []byte(`{"duration":1700000010,"data":"bar"}`), perm); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(9 * time.Second)
+
+	if res, _ := f.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	clock.Advance(time.Second)
+
+	if _, err := f.Fetch(testKey); !errors.Is(err, cachego.ErrCacheExpired) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheExpired, err)
+	}
+}
+
+func TestFileSubSecond(t *testing.T) {
+	clock := cachegotest.NewClock(time.Now())
+	c := New(t.TempDir(), WithClock(clock))
+
+	_ = c.Save(testKey, testValue, 500*time.Millisecond)
+
+	clock.Advance(499 * time.Millisecond)
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	clock.Advance(time.Millisecond)
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheExpired) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheExpired, err)
+	}
+}
+
+func TestFileJanitor(t *testing.T) {
+	clock := cachegotest.NewClock(time.Now())
+	c := New(t.TempDir(), WithClock(clock), WithJanitor(time.Millisecond)).(*file)
+
+	_ = c.Save(testKey, testValue, time.Second)
+	clock.Advance(2 * time.Second)
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if _, err := os.Stat(c.createName(testKey)); os.IsNotExist(err) {
+			break
+		}
+	}
+
+	if _, err := os.Stat(c.createName(testKey)); !os.IsNotExist(err) {
+		t.Errorf("janitor failed: the key %s should be purged, got %v", testKey, err)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Errorf("close failed: expected nil, got %v", err)
+	}
+}
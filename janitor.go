@@ -0,0 +1,60 @@
+package cachego
+
+import (
+	"sync"
+	"time"
+)
+
+// Janitor purges the expired keys of a cache in the background
+type Janitor struct {
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewJanitor creates an instance of Janitor purging the expired keys of
+// the cache every interval, until it is stopped. It returns nil when the
+// interval is not positive, stopping a nil Janitor does nothing.
+func NewJanitor(cache Purger, interval time.Duration) *Janitor {
+	if interval <= 0 {
+		return nil
+	}
+
+	j := &Janitor{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	go j.run(cache, interval)
+
+	return j
+}
+
+func (j *Janitor) run(cache Purger, interval time.Duration) {
+	defer close(j.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_, _ = cache.PurgeExpired()
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+// Stop stops the janitor, waiting for a running purge to finish
+func (j *Janitor) Stop() {
+	if j == nil {
+		return
+	}
+
+	j.once.Do(func() {
+		close(j.stop)
+	})
+
+	<-j.done
+}
@@ -0,0 +1,58 @@
+package cachego
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingPurger struct {
+	mapCache
+	calls int32
+}
+
+func (c *countingPurger) PurgeExpired() (int, error) {
+	atomic.AddInt32(&c.calls, 1)
+	return 0, nil
+}
+
+func TestJanitor(t *testing.T) {
+	c := &countingPurger{mapCache: mapCache{}}
+	j := NewJanitor(c, time.Millisecond)
+
+	for deadline := time.Now().Add(time.Second); atomic.LoadInt32(&c.calls) < 2 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+
+	j.Stop()
+	j.Stop()
+
+	calls := atomic.LoadInt32(&c.calls)
+
+	if calls < 2 {
+		t.Errorf("janitor failed: expected at least %d purges, got %d", 2, calls)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	if n := atomic.LoadInt32(&c.calls); n != calls {
+		t.Errorf("stop failed: expected %d purges, got %d", calls, n)
+	}
+}
+
+func TestJanitorInterval(t *testing.T) {
+	c := &countingPurger{mapCache: mapCache{}}
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		j := NewJanitor(c, interval)
+		if j != nil {
+			t.Errorf("janitor failed: expected nil for the interval %v, got %v", interval, j)
+		}
+
+		j.Stop()
+	}
+
+	if n := atomic.LoadInt32(&c.calls); n != 0 {
+		t.Errorf("janitor failed: expected %d purges, got %d", 0, n)
+	}
+}
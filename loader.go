@@ -0,0 +1,49 @@
+package cachego
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Loader wraps a cache with read-through loading, the concurrent misses of
+// the same key are collapsed into a single load.
+type Loader struct {
+	cache Cache
+	group singleflight.Group
+}
+
+// NewLoader creates an instance of Loader
+func NewLoader(cache Cache) *Loader {
+	return &Loader{cache: cache}
+}
+
+// GetOrLoad retrieves the cached value of the key, on a miss the value is
+// loaded and saved with the life time. The concurrent calls for the same key
+// share a single load, when the loaded value can not be saved it is returned
+// along with an error wrapping ErrSave.
+func (l *Loader) GetOrLoad(key string, lifeTime time.Duration, load func() (string, error)) (string, error) {
+	if value, err := l.cache.Fetch(key); err == nil {
+		return value, nil
+	}
+
+	value, err, _ := l.group.Do(key, func() (any, error) {
+		if value, err := l.cache.Fetch(key); err == nil {
+			return value, nil
+		}
+
+		value, err := load()
+		if err != nil {
+			return "", err
+		}
+
+		if err := l.cache.Save(key, value, lifeTime); err != nil {
+			return value, fmt.Errorf("%w: %w", ErrSave, err)
+		}
+
+		return value, nil
+	})
+
+	return value.(string), err
+}
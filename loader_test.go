@@ -0,0 +1,109 @@
+package cachego
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type lockedCache struct {
+	mapCache
+	mu *sync.Mutex
+}
+
+func (l lockedCache) Fetch(key string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.mapCache.Fetch(key)
+}
+
+func (l lockedCache) Save(key string, value string, lifeTime time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.mapCache.Save(key, value, lifeTime)
+}
+
+type failingCache struct {
+	mapCache
+}
+
+func (failingCache) Save(string, string, time.Duration) error {
+	return errors.New("unable to save")
+}
+
+func TestLoader(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls int32
+		wg    sync.WaitGroup
+	)
+
+	cache := mapCache{}
+	l := NewLoader(lockedCache{cache, &mu})
+
+	release := make(chan struct{})
+
+	load := func() (string, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "bar", nil
+	}
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if res, err := l.GetOrLoad("foo", 0, load); err != nil || res != "bar" {
+				t.Errorf("get or load fail, wrong value: expected %s, got %s (%v)", "bar", res, err)
+			}
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("get or load failed: expected %d load, got %d", 1, n)
+	}
+
+	if cache["foo"] != "bar" {
+		t.Errorf("save fail, wrong value: expected %s, got %s", "bar", cache["foo"])
+	}
+
+	res, _ := l.GetOrLoad("foo", 0, func() (string, error) {
+		t.Error("get or load failed: the loader should not be called on a hit")
+		return "", nil
+	})
+
+	if res != "bar" {
+		t.Errorf("get or load fail, wrong value: expected %s, got %s", "bar", res)
+	}
+
+	failure := errors.New("unable to load")
+
+	if _, err := l.GetOrLoad("baz", 0, func() (string, error) { return "", failure }); !errors.Is(err, failure) {
+		t.Errorf("get or load failed: expected %v, got %v", failure, err)
+	}
+
+	if _, ok := cache["baz"]; ok {
+		t.Errorf("get or load failed: the key %s should not be saved", "baz")
+	}
+
+	l = NewLoader(failingCache{mapCache{}})
+
+	res, err := l.GetOrLoad("foo", 0, func() (string, error) { return "bar", nil })
+	if !errors.Is(err, ErrSave) {
+		t.Errorf("get or load failed: expected %v, got %v", ErrSave, err)
+	}
+
+	if res != "bar" {
+		t.Errorf("get or load fail, wrong value: expected %s, got %s", "bar", res)
+	}
+}
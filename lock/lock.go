@@ -0,0 +1,263 @@
+// Package lock provides distributed locks kept in the cachego drivers.
+//
+// A lock is a key saved only when it is missing, holding the random id of its
+// lease so that only the holder releases or extends it. Each lease has a
+// fencing token, increased by every acquisition of the key, which lets the
+// resources guarded by the lock reject the writes of an expired lease.
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/faabiosr/cachego"
+)
+
+type (
+	// Store is the cache keeping the locks, such as the memcached, redis,
+	// sqlite3, bolt, mongo and sync drivers
+	Store interface {
+		cachego.AtomicCache
+		cachego.CASCache
+	}
+
+	// CompareStore is implemented by the stores deleting or expiring a key
+	// only while it holds a value in a single command, such as the redis
+	// driver by a script. The leases use it instead of the versions of
+	// cachego.CASCache, saving the round trip that reads the version.
+	CompareStore interface {
+		// CompareAndDelete removes the key while it holds the value
+		CompareAndDelete(ctx context.Context, key, value string) (bool, error)
+
+		// CompareAndExpire updates the life time of the key while it holds
+		// the value
+		CompareAndExpire(ctx context.Context, key, value string, lifeTime time.Duration) (bool, error)
+	}
+
+	// Locker acquires the locks kept in a store
+	Locker struct {
+		store  Store
+		prefix string
+		retry  time.Duration
+	}
+
+	// Option configures the Locker
+	Option func(*Locker)
+
+	// Lease is a held lock, until it is released or its life time passes
+	Lease struct {
+		store Store
+		key   string
+		id    string
+		token int64
+	}
+)
+
+const (
+	defaultPrefix = "cachego:"
+	defaultRetry  = 50 * time.Millisecond
+
+	idSize = 16
+)
+
+var (
+	// ErrLocked is returned when the lock is held by another lease
+	ErrLocked = errors.New("lock: the key is locked")
+
+	// ErrNotHeld is returned when the lease was released or has expired
+	ErrNotHeld = errors.New("lock: the lease is not held")
+
+	errLifeTime = errors.New("lock: the life time must be positive")
+)
+
+// New creates an instance of Locker
+func New(store Store, opts ...Option) *Locker {
+	l := &Locker{store: store, prefix: defaultPrefix, retry: defaultRetry}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// WithPrefix sets the prefix of the keys of the locks and their fencing
+// tokens, "cachego:" by default
+func WithPrefix(prefix string) Option {
+	return func(l *Locker) {
+		l.prefix = prefix
+	}
+}
+
+// WithRetry sets the interval between the attempts of Acquire, 50ms by default
+func WithRetry(interval time.Duration) Option {
+	return func(l *Locker) {
+		l.retry = interval
+	}
+}
+
+// Acquire waits for the lock of the key until the context is done, trying it
+// every retry interval. The lease expires after the life time unless it is
+// extended.
+func (l *Locker) Acquire(ctx context.Context, key string, lifeTime time.Duration) (*Lease, error) {
+	for {
+		lease, err := l.tryAcquire(ctx, key, lifeTime)
+		if !errors.Is(err, ErrLocked) {
+			return lease, err
+		}
+
+		timer := time.NewTimer(l.retry)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// TryAcquire takes the lock of the key, it returns ErrLocked when the lock is
+// held by another lease
+func (l *Locker) TryAcquire(key string, lifeTime time.Duration) (*Lease, error) {
+	return l.tryAcquire(context.Background(), key, lifeTime)
+}
+
+func (l *Locker) tryAcquire(ctx context.Context, key string, lifeTime time.Duration) (*Lease, error) {
+	if lifeTime <= 0 {
+		return nil, errLifeTime
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	lease := &Lease{store: l.store, key: l.prefix + "lock:" + key, id: id}
+
+	ok, err := l.store.SaveIfAbsent(lease.key, id, lifeTime)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, ErrLocked
+	}
+
+	if lease.token, err = l.store.Incr(l.prefix+"fence:"+key, 1); err != nil {
+		_ = lease.Release()
+		return nil, err
+	}
+
+	// the lease is granted only when it is still held after taking its
+	// token, so that the tokens of the later leases are greater
+	value, err := cachego.NewContextCache(l.store).FetchContext(ctx, lease.key)
+
+	switch {
+	case errors.Is(err, cachego.ErrCacheMiss):
+		return nil, ErrLocked
+	case err != nil:
+		_ = lease.Release()
+		return nil, err
+	case value != id:
+		return nil, ErrLocked
+	}
+
+	return lease, nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, idSize)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// Token returns the fencing token of the lease, greater than the tokens of
+// the previous leases of the key
+func (l *Lease) Token() int64 {
+	return l.token
+}
+
+// Release unlocks the key, it returns ErrNotHeld when the lease was released
+// or has expired
+func (l *Lease) Release() error {
+	return l.ReleaseContext(context.Background())
+}
+
+// ReleaseContext unlocks the key, it returns ErrNotHeld when the lease was
+// released or has expired
+func (l *Lease) ReleaseContext(ctx context.Context) error {
+	if s, ok := l.store.(CompareStore); ok {
+		return held(s.CompareAndDelete(ctx, l.key, l.id))
+	}
+
+	return l.ifHeld(ctx, func(version string) error {
+		return l.store.DeleteIfVersion(l.key, version)
+	})
+}
+
+// Extend sets the life time of the lease from now, it returns ErrNotHeld when
+// the lease was released or has expired
+func (l *Lease) Extend(lifeTime time.Duration) error {
+	return l.ExtendContext(context.Background(), lifeTime)
+}
+
+// ExtendContext sets the life time of the lease from now, it returns
+// ErrNotHeld when the lease was released or has expired
+func (l *Lease) ExtendContext(ctx context.Context, lifeTime time.Duration) error {
+	if lifeTime <= 0 {
+		return errLifeTime
+	}
+
+	if s, ok := l.store.(CompareStore); ok {
+		return held(s.CompareAndExpire(ctx, l.key, l.id, lifeTime))
+	}
+
+	return l.ifHeld(ctx, func(version string) error {
+		return l.store.SaveIfVersion(l.key, l.id, version, lifeTime)
+	})
+}
+
+// held converts the result of a CompareStore operation
+func held(ok bool, err error) error {
+	if err == nil && !ok {
+		return ErrNotHeld
+	}
+
+	return err
+}
+
+// ifHeld calls fn with the version of the lock while it holds the id of the
+// lease
+func (l *Lease) ifHeld(ctx context.Context, fn func(version string) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	value, version, err := l.store.FetchWithVersion(l.key)
+	if errors.Is(err, cachego.ErrCacheMiss) || (err == nil && value != l.id) {
+		return ErrNotHeld
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = fn(version)
+	if errors.Is(err, cachego.ErrVersionMismatch) {
+		return ErrNotHeld
+	}
+
+	return err
+}
@@ -0,0 +1,335 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	_ "github.com/mattn/go-sqlite3"
+	rd "github.com/redis/go-redis/v9"
+	bt "go.etcd.io/bbolt"
+
+	"github.com/faabiosr/cachego/bolt"
+	"github.com/faabiosr/cachego/cachegotest"
+	"github.com/faabiosr/cachego/redis"
+	"github.com/faabiosr/cachego/sqlite3"
+	syncmap "github.com/faabiosr/cachego/sync"
+)
+
+const (
+	testKey = "foo"
+
+	workers    = 4
+	iterations = 10
+)
+
+func newLocker(t *testing.T) (*Locker, *cachegotest.Clock) {
+	t.Helper()
+
+	clock := cachegotest.NewClock(time.Now())
+	store := syncmap.New(syncmap.WithClock(clock)).(Store)
+
+	return New(store, WithRetry(time.Millisecond)), clock
+}
+
+func TestLock(t *testing.T) {
+	l, _ := newLocker(t)
+
+	lease, err := l.TryAcquire(testKey, time.Minute)
+	if err != nil {
+		t.Fatalf("acquire failed: expected nil, got %v", err)
+	}
+
+	if _, err := l.TryAcquire(testKey, time.Minute); !errors.Is(err, ErrLocked) {
+		t.Errorf("acquire failed: expected %v, got %v", ErrLocked, err)
+	}
+
+	if other, err := l.TryAcquire("bar", time.Minute); err != nil || other.Token() != 1 {
+		t.Errorf("acquire failed: expected the token %d of another key, got %v", 1, err)
+	}
+
+	if err := lease.Release(); err != nil {
+		t.Errorf("release failed: expected nil, got %v", err)
+	}
+
+	if err := lease.Release(); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("release failed: expected %v, got %v", ErrNotHeld, err)
+	}
+
+	next, err := l.TryAcquire(testKey, time.Minute)
+	if err != nil {
+		t.Fatalf("acquire failed: expected nil, got %v", err)
+	}
+
+	if next.Token() <= lease.Token() {
+		t.Errorf("acquire failed: expected a token greater than %d, got %d", lease.Token(), next.Token())
+	}
+}
+
+func TestLockExpiration(t *testing.T) {
+	l, clock := newLocker(t)
+
+	lease, _ := l.TryAcquire(testKey, time.Second)
+
+	clock.Advance(2 * time.Second)
+
+	next, err := l.TryAcquire(testKey, time.Second)
+	if err != nil {
+		t.Fatalf("acquire failed: expected nil for an expired lease, got %v", err)
+	}
+
+	if next.Token() <= lease.Token() {
+		t.Errorf("acquire failed: expected a token greater than %d, got %d", lease.Token(), next.Token())
+	}
+
+	if err := lease.Extend(time.Second); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("extend failed: expected %v, got %v", ErrNotHeld, err)
+	}
+
+	if err := lease.Release(); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("release failed: expected %v, got %v", ErrNotHeld, err)
+	}
+
+	if _, err := l.TryAcquire(testKey, time.Second); !errors.Is(err, ErrLocked) {
+		t.Errorf("release failed: the expired lease should not unlock the key, got %v", err)
+	}
+}
+
+func TestLockExtend(t *testing.T) {
+	l, clock := newLocker(t)
+
+	lease, _ := l.TryAcquire(testKey, time.Second)
+
+	clock.Advance(500 * time.Millisecond)
+
+	if err := lease.Extend(time.Second); err != nil {
+		t.Errorf("extend failed: expected nil, got %v", err)
+	}
+
+	clock.Advance(800 * time.Millisecond)
+
+	if _, err := l.TryAcquire(testKey, time.Second); !errors.Is(err, ErrLocked) {
+		t.Errorf("extend failed: expected %v, got %v", ErrLocked, err)
+	}
+
+	clock.Advance(500 * time.Millisecond)
+
+	if _, err := l.TryAcquire(testKey, time.Second); err != nil {
+		t.Errorf("acquire failed: expected nil, got %v", err)
+	}
+}
+
+func TestLockLifeTime(t *testing.T) {
+	l, _ := newLocker(t)
+
+	if _, err := l.TryAcquire(testKey, 0); err == nil {
+		t.Errorf("acquire failed: expected an error, got %v", err)
+	}
+
+	lease, _ := l.TryAcquire(testKey, time.Second)
+
+	if err := lease.Extend(0); err == nil {
+		t.Errorf("extend failed: expected an error, got %v", err)
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	l, _ := newLocker(t)
+
+	lease, _ := l.TryAcquire(testKey, time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := l.Acquire(ctx, testKey, time.Minute); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire failed: expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	time.AfterFunc(20*time.Millisecond, func() { _ = lease.Release() })
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := l.Acquire(ctx, testKey, time.Minute); err != nil {
+		t.Errorf("acquire failed: expected nil once released, got %v", err)
+	}
+}
+
+func TestLockContext(t *testing.T) {
+	l, _ := newLocker(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := l.Acquire(ctx, testKey, time.Minute); !errors.Is(err, context.Canceled) {
+		t.Errorf("acquire failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	lease, _ := l.TryAcquire(testKey, time.Minute)
+
+	if err := lease.ExtendContext(ctx, time.Minute); !errors.Is(err, context.Canceled) {
+		t.Errorf("extend failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if err := lease.ReleaseContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("release failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if err := lease.Release(); err != nil {
+		t.Errorf("release failed: expected nil, got %v", err)
+	}
+}
+
+func TestLockCompareStore(t *testing.T) {
+	m := miniredis.RunT(t)
+	conn := rd.NewClient(&rd.Options{Addr: m.Addr()})
+	t.Cleanup(func() { _ = conn.Close() })
+
+	store := redis.New(conn).(Store)
+
+	if _, ok := store.(CompareStore); !ok {
+		t.Fatal("compare store failed: expected the redis driver to implement it")
+	}
+
+	l := New(store)
+
+	lease, err := l.TryAcquire(testKey, time.Second)
+	if err != nil {
+		t.Fatalf("acquire failed: expected nil, got %v", err)
+	}
+
+	if err := lease.Extend(time.Minute); err != nil {
+		t.Errorf("extend failed: expected nil, got %v", err)
+	}
+
+	if ttl := m.TTL("cachego:lock:" + testKey); ttl != time.Minute {
+		t.Errorf("extend failed, wrong life time: expected %v, got %v", time.Minute, ttl)
+	}
+
+	m.FastForward(2 * time.Minute)
+
+	if err := lease.Extend(time.Minute); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("extend failed: expected %v, got %v", ErrNotHeld, err)
+	}
+
+	next, _ := l.TryAcquire(testKey, time.Minute)
+
+	if err := lease.Release(); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("release failed: expected %v, got %v", ErrNotHeld, err)
+	}
+
+	if err := next.Release(); err != nil {
+		t.Errorf("release failed: expected nil, got %v", err)
+	}
+
+	if m.Exists("cachego:lock:" + testKey) {
+		t.Errorf("release failed: the lock of %s should be removed", testKey)
+	}
+}
+
+func TestLockContention(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"sync": func(*testing.T) Store {
+			return syncmap.New().(Store)
+		},
+		"bolt": func(t *testing.T) Store {
+			db, err := bt.Open(filepath.Join(t.TempDir(), "cache.db"), 0o600, nil)
+			if err != nil {
+				t.Skip(err)
+			}
+
+			t.Cleanup(func() { _ = db.Close() })
+
+			return bolt.New(db).(Store)
+		},
+		"sqlite3": func(t *testing.T) Store {
+			db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "cache.db"))
+			if err != nil {
+				t.Skip(err)
+			}
+
+			t.Cleanup(func() { _ = db.Close() })
+
+			c, err := sqlite3.New(db, "cache")
+			if err != nil {
+				t.Skip(err)
+			}
+
+			return c.(Store)
+		},
+		"redis": func(t *testing.T) Store {
+			conn := rd.NewClient(&rd.Options{Addr: miniredis.RunT(t).Addr()})
+			t.Cleanup(func() { _ = conn.Close() })
+
+			return redis.New(conn).(Store)
+		},
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			testContention(t, New(store(t), WithRetry(time.Millisecond)))
+		})
+	}
+}
+
+// testContention acquires the same key from several workers, only one of
+// them may hold it at a time and the tokens increase in the order it is held
+func testContention(t *testing.T, l *Locker) {
+	var (
+		holders atomic.Int32
+		mu      sync.Mutex
+		tokens  []int64
+		wg      sync.WaitGroup
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < iterations; i++ {
+				lease, err := l.Acquire(ctx, testKey, time.Minute)
+				if err != nil {
+					t.Errorf("acquire failed: expected nil, got %v", err)
+					return
+				}
+
+				if n := holders.Add(1); n != 1 {
+					t.Errorf("acquire failed: expected a single holder, got %d", n)
+				}
+
+				mu.Lock()
+				tokens = append(tokens, lease.Token())
+				mu.Unlock()
+
+				holders.Add(-1)
+
+				if err := lease.Release(); err != nil {
+					t.Errorf("release failed: expected nil, got %v", err)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if len(tokens) != workers*iterations {
+		t.Fatalf("acquire failed: expected %d leases, got %d", workers*iterations, len(tokens))
+	}
+
+	for i := 1; i < len(tokens); i++ {
+		if tokens[i] <= tokens[i-1] {
+			t.Errorf("acquire failed: expected increasing tokens, got %d after %d", tokens[i], tokens[i-1])
+		}
+	}
+}
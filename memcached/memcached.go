@@ -2,53 +2,195 @@
 package memcached
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bradfitz/gomemcache/memcache"
 	"github.com/faabiosr/cachego"
 )
 
-type memcached struct {
-	driver *memcache.Client
-}
+type (
+	memcached struct {
+		driver   *memcache.Client
+		clock    cachego.Clock
+		hashKeys bool
+	}
+
+	// Option configures the Memcached cache driver
+	Option func(*memcached)
+)
+
+const (
+	// maxRelative is the longest expiration in seconds taken as relative by
+	// the server, the longer ones are taken as an unix timestamp
+	maxRelative = 30 * 24 * 60 * 60
+
+	// maxKeyLength is the longest key accepted by the server
+	maxKeyLength = 250
+
+	// del is the last control character rejected in the keys
+	del = 0x7f
+
+	// hashPrefix starts the hashed keys, the keys starting with it are hashed
+	// too so that no other key is stored by the same name
+	hashPrefix = "sha256:"
+
+	// expired is the expiration of the items expired right away
+	expired = -1
+)
 
 // New creates an instance of Memcached cache driver
-func New(driver *memcache.Client) cachego.Cache {
-	return &memcached{driver}
+func New(driver *memcache.Client, opts ...Option) cachego.Cache {
+	m := &memcached{driver: driver, clock: cachego.SystemClock{}}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// WithClock sets the clock used to compute the expiration of the life times
+// longer than 30 days
+func WithClock(clock cachego.Clock) Option {
+	return func(m *memcached) {
+		m.clock = clock
+	}
+}
+
+// WithKeyHashing stores the keys rejected by the server, longer than 250
+// bytes or with spaces or control characters, by their SHA-256 in hex after
+// the "sha256:" prefix, which is hashed as well when a key starts with it
+func WithKeyHashing() Option {
+	return func(m *memcached) {
+		m.hashKeys = true
+	}
 }
 
 // Contains checks if cached key exists in Memcached storage
 func (m *memcached) Contains(key string) bool {
-	_, err := m.Fetch(key)
+	return m.ContainsContext(context.Background(), key)
+}
+
+// ContainsContext checks if cached key exists in Memcached storage
+func (m *memcached) ContainsContext(ctx context.Context, key string) bool {
+	_, err := m.FetchContext(ctx, key)
 	return err == nil
 }
 
 // Delete the cached key from Memcached storage
 func (m *memcached) Delete(key string) error {
-	return m.driver.Delete(key)
+	return m.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext the cached key from Memcached storage
+func (m *memcached) DeleteContext(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return m.driver.Delete(m.key(key))
 }
 
 // Fetch retrieves the cached value from key of the Memcached storage
 func (m *memcached) Fetch(key string) (string, error) {
-	item, err := m.driver.Get(key)
+	return m.FetchContext(context.Background(), key)
+}
+
+// FetchContext retrieves the cached value from key of the Memcached storage
+func (m *memcached) FetchContext(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	value, err := m.FetchBytes(key)
 	if err != nil {
 		return "", err
 	}
 
-	return string(item.Value), nil
+	return string(value), nil
+}
+
+// FetchBytes retrieves the cached binary value from key of the Memcached storage
+func (m *memcached) FetchBytes(key string) ([]byte, error) {
+	item, err := m.driver.Get(m.key(key))
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return nil, cachego.ErrCacheMiss
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return item.Value, nil
+}
+
+// FetchWithVersion retrieves the cached value from key of the Memcached
+// storage and its CAS identifier as version
+func (m *memcached) FetchWithVersion(key string) (string, string, error) {
+	item, err := m.driver.Get(m.key(key))
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return "", "", cachego.ErrCacheMiss
+	}
+
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(item.Value), strconv.FormatUint(item.CasID, 10), nil
 }
 
 // FetchMulti retrieves multiple cached value from keys of the Memcached storage
 func (m *memcached) FetchMulti(keys []string) map[string]string {
+	return m.FetchMultiContext(context.Background(), keys)
+}
+
+// FetchMultiContext retrieves multiple cached value from keys of the Memcached storage
+func (m *memcached) FetchMultiContext(ctx context.Context, keys []string) map[string]string {
 	result := make(map[string]string)
 
-	items, err := m.driver.GetMulti(keys)
+	if ctx.Err() != nil {
+		return result
+	}
+
+	for key, value := range m.fetchMulti(keys) {
+		result[key] = string(value)
+	}
+
+	return result
+}
+
+// FetchMultiBytes retrieves multiple cached binary value from keys of the Memcached storage
+func (m *memcached) FetchMultiBytes(keys []string) map[string][]byte {
+	return m.fetchMulti(keys)
+}
+
+// fetchMulti retrieves the items of the keys, the result is keyed by the
+// keys requested, not the hashed ones
+func (m *memcached) fetchMulti(keys []string) map[string][]byte {
+	result := make(map[string][]byte)
+	names := make(map[string]string, len(keys))
+	stored := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		name := m.key(key)
+		names[name] = key
+		stored = append(stored, name)
+	}
+
+	items, err := m.driver.GetMulti(stored)
 	if err != nil {
 		return result
 	}
 
-	for _, i := range items {
-		result[i.Key] = string(i.Value)
+	for name, i := range items {
+		result[names[name]] = i.Value
 	}
 
 	return result
@@ -56,16 +198,210 @@ func (m *memcached) FetchMulti(keys []string) map[string]string {
 
 // Flush removes all cached keys of the Memcached storage
 func (m *memcached) Flush() error {
+	return m.FlushContext(context.Background())
+}
+
+// FlushContext removes all cached keys of the Memcached storage
+func (m *memcached) FlushContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	return m.driver.FlushAll()
 }
 
 // Save a value in Memcached storage by key
 func (m *memcached) Save(key string, value string, lifeTime time.Duration) error {
-	return m.driver.Set(
-		&memcache.Item{
-			Key:        key,
-			Value:      []byte(value),
-			Expiration: int32(lifeTime.Seconds()),
-		},
-	)
+	return m.SaveContext(context.Background(), key, value, lifeTime)
+}
+
+// SaveContext a value in Memcached storage by key
+func (m *memcached) SaveContext(ctx context.Context, key string, value string, lifeTime time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return m.SaveBytes(key, []byte(value), lifeTime)
+}
+
+// SaveBytes a binary value in Memcached storage by key
+func (m *memcached) SaveBytes(key string, value []byte, lifeTime time.Duration) error {
+	return m.driver.Set(m.item(key, value, lifeTime))
+}
+
+// item returns the item of the key expiring after the life time
+func (m *memcached) item(key string, value []byte, lifeTime time.Duration) *memcache.Item {
+	return &memcache.Item{
+		Key:        m.key(key),
+		Value:      value,
+		Expiration: m.expiration(lifeTime),
+	}
+}
+
+// SaveIfVersion a value in Memcached storage by key while its CAS identifier
+// is the version, by ADD when the version is empty
+func (m *memcached) SaveIfVersion(key, value, version string, lifeTime time.Duration) error {
+	item := m.item(key, []byte(value), lifeTime)
+
+	if version == "" {
+		return casErr(m.driver.Add(item))
+	}
+
+	casID, err := strconv.ParseUint(version, 10, 64)
+	if err != nil {
+		return cachego.ErrVersionMismatch
+	}
+
+	item.CasID = casID
+
+	return casErr(m.driver.CompareAndSwap(item))
+}
+
+// SaveIfAbsent a value in Memcached storage by key by ADD, only when the key
+// is missing
+func (m *memcached) SaveIfAbsent(key string, value string, lifeTime time.Duration) (bool, error) {
+	return stored(m.driver.Add(m.item(key, []byte(value), lifeTime)))
+}
+
+// Replace a value in Memcached storage by key by REPLACE, only when the key
+// exists
+func (m *memcached) Replace(key string, value string, lifeTime time.Duration) (bool, error) {
+	return stored(m.driver.Replace(m.item(key, []byte(value), lifeTime)))
+}
+
+// stored returns whether the conditional write stored the item
+func stored(err error) (bool, error) {
+	if errors.Is(err, memcache.ErrNotStored) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// Touch updates the life time of the cached key in Memcached storage
+func (m *memcached) Touch(key string, lifeTime time.Duration) error {
+	err := m.driver.Touch(m.key(key), m.expiration(lifeTime))
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return cachego.ErrCacheMiss
+	}
+
+	return err
+}
+
+// Incr increments the value of the cached key in Memcached storage, the
+// values are unsigned so that the server stops decrementing them at zero
+func (m *memcached) Incr(key string, delta int64) (int64, error) {
+	for {
+		value, err := m.incr(m.key(key), delta)
+		if err == nil {
+			return int64(value), nil
+		}
+
+		if !errors.Is(err, memcache.ErrCacheMiss) {
+			return 0, countErr(err)
+		}
+
+		// the missing key is added, unless another client added it first
+		initial := max(delta, 0)
+
+		ok, err := stored(m.driver.Add(m.item(key, []byte(strconv.FormatInt(initial, 10)), 0)))
+		if err != nil {
+			return 0, err
+		}
+
+		if ok {
+			return initial, nil
+		}
+	}
+}
+
+// Decr decrements the value of the cached key in Memcached storage, as Incr
+// does
+func (m *memcached) Decr(key string, delta int64) (int64, error) {
+	return m.Incr(key, -delta)
+}
+
+func (m *memcached) incr(key string, delta int64) (uint64, error) {
+	if delta < 0 {
+		return m.driver.Decrement(key, uint64(-delta))
+	}
+
+	return m.driver.Increment(key, uint64(delta))
+}
+
+// countErr converts the server error of the non-numeric values
+func countErr(err error) error {
+	if strings.Contains(err.Error(), "non-numeric value") {
+		return cachego.ErrNotInteger
+	}
+
+	return err
+}
+
+// DeleteIfVersion removes the cached key from Memcached storage while its CAS
+// identifier is the version, by swapping it for an item already expired
+func (m *memcached) DeleteIfVersion(key, version string) error {
+	casID, err := strconv.ParseUint(version, 10, 64)
+	if err != nil {
+		return cachego.ErrVersionMismatch
+	}
+
+	item := &memcache.Item{Key: m.key(key), Value: []byte{}, Expiration: expired, CasID: casID}
+
+	return casErr(m.driver.CompareAndSwap(item))
+}
+
+// casErr converts the failures of the conditional writes into a version mismatch
+func casErr(err error) error {
+	switch {
+	case errors.Is(err, memcache.ErrCASConflict),
+		errors.Is(err, memcache.ErrNotStored),
+		errors.Is(err, memcache.ErrCacheMiss):
+		return cachego.ErrVersionMismatch
+	}
+
+	return err
+}
+
+// expiration returns the expiration of the item from the life time, rounded
+// up to a second so that a sub-second life time does not become endless, and
+// as an unix timestamp when it is longer than the server takes as relative
+func (m *memcached) expiration(lifeTime time.Duration) int32 {
+	if lifeTime <= 0 {
+		return 0
+	}
+
+	seconds := math.Ceil(lifeTime.Seconds())
+	if seconds <= maxRelative {
+		return int32(seconds)
+	}
+
+	return int32(m.clock.Now().Add(lifeTime).Unix())
+}
+
+// key returns the key stored in the server, the keys rejected by it or
+// starting with the hash prefix are hashed when the key hashing is enabled
+func (m *memcached) key(key string) string {
+	if !m.hashKeys || (validKey(key) && !strings.HasPrefix(key, hashPrefix)) {
+		return key
+	}
+
+	sum := sha256.Sum256([]byte(key))
+
+	return hashPrefix + hex.EncodeToString(sum[:])
+}
+
+// validKey checks if the key is accepted by the server
+func validKey(key string) bool {
+	if len(key) > maxKeyLength {
+		return false
+	}
+
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == del {
+			return false
+		}
+	}
+
+	return true
 }
@@ -1,11 +1,19 @@
 package memcached
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"net"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/faabiosr/cachego"
+	"github.com/faabiosr/cachego/cachegotest"
 )
 
 const (
@@ -63,3 +71,261 @@ func TestMemcached(t *testing.T) {
 		t.Errorf("fetch multi failed: expected %d, got %d", 0, len(values))
 	}
 }
+
+func TestMemcachedContext(t *testing.T) {
+	address := "localhost:11211"
+
+	if _, err := net.Dial("tcp", address); err != nil {
+		t.Skip(err)
+	}
+
+	c := New(memcache.New(address)).(cachego.ContextCache)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if err := c.SaveContext(ctx, testKey, testValue, 0); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.FetchContext(ctx, testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	cancel()
+
+	if err := c.SaveContext(ctx, testKey, testValue, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("save failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if _, err := c.FetchContext(ctx, testKey); !errors.Is(err, context.Canceled) {
+		t.Errorf("fetch failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if err := c.DeleteContext(ctx, testKey); !errors.Is(err, context.Canceled) {
+		t.Errorf("delete failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if err := c.FlushContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("flush failed: expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestMemcachedBytes(t *testing.T) {
+	address := "localhost:11211"
+
+	if _, err := net.Dial("tcp", address); err != nil {
+		t.Skip(err)
+	}
+
+	c := New(memcache.New(address)).(cachego.BytesCache)
+	value := []byte{0x00, 0xff, 0xfe, '"', '\\'}
+
+	if err := c.SaveBytes(testKey, value, 10*time.Second); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.FetchBytes(testKey); !bytes.Equal(res, value) {
+		t.Errorf("fetch fail, wrong value: expected %v, got %v", value, res)
+	}
+
+	if values := c.FetchMultiBytes([]string{testKey, "baz"}); !bytes.Equal(values[testKey], value) {
+		t.Errorf("fetch multi failed, wrong value: expected %v, got %v", value, values[testKey])
+	}
+}
+
+func TestMemcachedCacheMiss(t *testing.T) {
+	address := "localhost:11211"
+
+	if _, err := net.Dial("tcp", address); err != nil {
+		t.Skip(err)
+	}
+
+	c := New(memcache.New(address))
+	_ = c.Delete(testKey)
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+
+	if _, err := c.(cachego.BytesCache).FetchBytes(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+}
+
+func TestMemcachedStale(t *testing.T) {
+	address := "localhost:11211"
+
+	if _, err := net.Dial("tcp", address); err != nil {
+		t.Skip(err)
+	}
+
+	c := cachego.NewStaleCache(New(memcache.New(address)))
+
+	if err := c.SaveStale(testKey, testValue, 1*time.Nanosecond, 10*time.Second); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, stale, _ := c.FetchStale(testKey); res != testValue || !stale {
+		t.Errorf("fetch stale fail: expected a stale %s, got %s (stale %v)", testValue, res, stale)
+	}
+
+	if values := c.FetchMulti([]string{testKey}); values[testKey] != testValue {
+		t.Errorf("fetch multi failed, wrong value: expected %s, got %s", testValue, values[testKey])
+	}
+
+	_ = c.SaveStale(testKey, testValue, 10*time.Second, 0)
+
+	if _, stale, _ := c.FetchStale(testKey); stale {
+		t.Errorf("fetch stale fail: the key %s should not be stale", testKey)
+	}
+}
+
+func TestMemcachedSuite(t *testing.T) {
+	address := "localhost:11211"
+
+	if _, err := net.Dial("tcp", address); err != nil {
+		t.Skip(err)
+	}
+
+	cachegotest.RunSuite(t, func(*testing.T) cachego.Cache {
+		return New(memcache.New(address))
+	})
+}
+
+func TestCASErr(t *testing.T) {
+	for _, err := range []error{memcache.ErrCASConflict, memcache.ErrNotStored, memcache.ErrCacheMiss} {
+		if !errors.Is(casErr(err), cachego.ErrVersionMismatch) {
+			t.Errorf("cas failed: expected %v for %v, got %v", cachego.ErrVersionMismatch, err, casErr(err))
+		}
+	}
+
+	if err := casErr(memcache.ErrServerError); !errors.Is(err, memcache.ErrServerError) {
+		t.Errorf("cas failed: expected %v, got %v", memcache.ErrServerError, err)
+	}
+}
+
+func TestExpiration(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := New(nil, WithClock(cachegotest.NewClock(now))).(*memcached)
+
+	tests := []struct {
+		lifeTime time.Duration
+		expected int32
+	}{
+		{0, 0},
+		{-time.Second, 0},
+		{time.Nanosecond, 1},
+		{500 * time.Millisecond, 1},
+		{1500 * time.Millisecond, 2},
+		{time.Hour, 3600},
+		{30 * 24 * time.Hour, maxRelative},
+		{60 * 24 * time.Hour, int32(now.Add(60 * 24 * time.Hour).Unix())},
+	}
+
+	for _, test := range tests {
+		if exp := m.expiration(test.lifeTime); exp != test.expected {
+			t.Errorf("expiration failed, wrong value of %s: expected %d, got %d", test.lifeTime, test.expected, exp)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	long := strings.Repeat("a", maxKeyLength+1)
+	plain := New(nil).(*memcached)
+	hashed := New(nil, WithKeyHashing()).(*memcached)
+
+	for _, key := range []string{testKey, "with space", "new\nline", long} {
+		if name := plain.key(key); name != key {
+			t.Errorf("key failed, wrong value: expected %q, got %q", key, name)
+		}
+	}
+
+	if name := hashed.key(testKey); name != testKey {
+		t.Errorf("key failed, wrong value: expected %q, got %q", testKey, name)
+	}
+
+	for _, key := range []string{"with space", "new\nline", "del\x7f", long} {
+		if name := hashed.key(key); !validKey(name) || name == hashed.key(key+"!") {
+			t.Errorf("key failed: expected a valid and distinct name of %q, got %q", key, name)
+		}
+	}
+
+	for _, key := range []string{sha256Hex("with space"), hashed.key("with space")} {
+		if name := hashed.key(key); !validKey(name) || name == hashed.key("with space") {
+			t.Errorf("key failed: expected a name of %q distinct from the hashed key, got %q", key, name)
+		}
+	}
+}
+
+// sha256Hex returns the SHA-256 of the key in hex
+func sha256Hex(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestMemcachedKeyHashing(t *testing.T) {
+	address := "localhost:11211"
+
+	if _, err := net.Dial("tcp", address); err != nil {
+		t.Skip(err)
+	}
+
+	c := New(memcache.New(address), WithKeyHashing())
+	keys := []string{
+		"with space",
+		sha256Hex("with space"),
+		hashPrefix + sha256Hex("with space"),
+		strings.Repeat("a", maxKeyLength+1),
+		testKey,
+	}
+
+	for _, key := range keys {
+		if err := c.Save(key, key, time.Minute); err != nil {
+			t.Errorf("save fail: expected nil, got %v", err)
+		}
+	}
+
+	for _, key := range keys {
+		if value, _ := c.Fetch(key); value != key {
+			t.Errorf("fetch failed, wrong value of %q: expected %s, got %s", key, key, value)
+		}
+	}
+
+	values := c.FetchMulti(keys)
+
+	for _, key := range keys {
+		if values[key] != key {
+			t.Errorf("fetch multi failed, wrong value of %q: expected %s, got %s", key, key, values[key])
+		}
+	}
+
+	if err := c.Delete(keys[0]); err != nil || c.Contains(keys[0]) {
+		t.Errorf("delete failed: the key %q should not be exist, got %v", keys[0], err)
+	}
+}
+
+func TestCountErr(t *testing.T) {
+	err := errors.New("memcache: client error: cannot increment or decrement non-numeric value")
+
+	if !errors.Is(countErr(err), cachego.ErrNotInteger) {
+		t.Errorf("count failed: expected %v, got %v", cachego.ErrNotInteger, countErr(err))
+	}
+
+	if err := countErr(memcache.ErrServerError); !errors.Is(err, memcache.ErrServerError) {
+		t.Errorf("count failed: expected %v, got %v", memcache.ErrServerError, err)
+	}
+}
+
+func TestStored(t *testing.T) {
+	if ok, err := stored(memcache.ErrNotStored); ok || err != nil {
+		t.Errorf("stored failed: expected false and nil, got %v (%v)", ok, err)
+	}
+
+	if ok, err := stored(nil); !ok || err != nil {
+		t.Errorf("stored failed: expected true and nil, got %v (%v)", ok, err)
+	}
+
+	if ok, err := stored(memcache.ErrServerError); ok || err == nil {
+		t.Errorf("stored failed: expected false and an error, got %v (%v)", ok, err)
+	}
+}
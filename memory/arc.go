@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"container/list"
+)
+
+type (
+	// arc is the Adaptive Replacement Cache policy, it balances between the
+	// keys used once (t1) and the keys used again (t2), adapting the target
+	// size of t1 (p) with the history of the evicted keys (b1 and b2)
+	arc struct {
+		size           int
+		p              int
+		t1, t2, b1, b2 *arcList
+	}
+
+	arcList struct {
+		order    *list.List
+		elements map[string]*list.Element
+	}
+)
+
+// NewARC creates the Adaptive Replacement Cache Policy, which resists to
+// scans by keeping apart the keys used once. The size is the expected
+// number of cached keys, bounding the history of evicted keys.
+func NewARC(size int) Policy {
+	return &arc{
+		size: size,
+		t1:   newARCList(),
+		t2:   newARCList(),
+		b1:   newARCList(),
+		b2:   newARCList(),
+	}
+}
+
+// Add tracks the new key, a key found in the history adapts the policy
+func (a *arc) Add(key string) {
+	switch {
+	case a.b1.contains(key):
+		a.p = min(a.size, a.p+max(a.b2.len()/max(a.b1.len(), 1), 1))
+		a.b1.remove(key)
+		a.t2.pushFront(key)
+	case a.b2.contains(key):
+		a.p = max(0, a.p-max(a.b1.len()/max(a.b2.len(), 1), 1))
+		a.b2.remove(key)
+		a.t2.pushFront(key)
+	default:
+		a.t1.pushFront(key)
+	}
+}
+
+// Access moves the key to the frequently used keys
+func (a *arc) Access(key string) {
+	if a.t1.remove(key) || a.t2.remove(key) {
+		a.t2.pushFront(key)
+	}
+}
+
+// Remove stops tracking the key
+func (a *arc) Remove(key string) {
+	_ = a.t1.remove(key) || a.t2.remove(key)
+}
+
+// Evict removes and returns the key from the list over its target size,
+// keeping it in the history
+func (a *arc) Evict() (string, bool) {
+	from, history := a.t2, a.b2
+
+	if a.t1.len() > 0 && (a.t1.len() > a.p || a.t2.len() == 0) {
+		from, history = a.t1, a.b1
+	}
+
+	key, ok := from.popBack()
+	if !ok {
+		return "", false
+	}
+
+	history.pushFront(key)
+
+	if history.len() > a.size {
+		_, _ = history.popBack()
+	}
+
+	return key, true
+}
+
+func newARCList() *arcList {
+	return &arcList{list.New(), make(map[string]*list.Element)}
+}
+
+func (l *arcList) len() int {
+	return l.order.Len()
+}
+
+func (l *arcList) contains(key string) bool {
+	_, ok := l.elements[key]
+	return ok
+}
+
+func (l *arcList) pushFront(key string) {
+	l.elements[key] = l.order.PushFront(key)
+}
+
+func (l *arcList) remove(key string) bool {
+	e, ok := l.elements[key]
+	if ok {
+		l.order.Remove(e)
+		delete(l.elements, key)
+	}
+
+	return ok
+}
+
+func (l *arcList) popBack() (string, bool) {
+	e := l.order.Back()
+	if e == nil {
+		return "", false
+	}
+
+	key := e.Value.(string)
+	l.remove(key)
+
+	return key, true
+}
@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"testing"
+)
+
+func TestARC(t *testing.T) {
+	a := NewARC(2)
+
+	a.Add("a")
+	a.Add("b")
+	a.Access("a")
+
+	if key, _ := a.Evict(); key != "b" {
+		t.Errorf("evict failed: expected the key used once %s, got %s", "b", key)
+	}
+
+	a.Add("c")
+	a.Remove("c")
+
+	if key, _ := a.Evict(); key != "a" {
+		t.Errorf("evict failed: expected %s, got %s", "a", key)
+	}
+
+	if _, ok := a.Evict(); ok {
+		t.Error("evict failed: expected no key to evict")
+	}
+
+	// b is in the history of the keys used once, adding it again grows
+	// the target of these keys
+	a.Add("b")
+
+	if p := a.(*arc).p; p != 1 {
+		t.Errorf("add failed: expected the target %d, got %d", 1, p)
+	}
+
+	if !a.(*arc).t2.contains("b") {
+		t.Errorf("add failed: the key %s should be frequently used", "b")
+	}
+}
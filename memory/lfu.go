@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"container/heap"
+)
+
+// lfuAging is the number of accesses per tracked key after which the
+// frequencies are halved, so the keys popular in the past can be evicted
+const lfuAging = 8
+
+type (
+	// lfu evicts the least frequently used key, the least recently used
+	// among the ones with the same frequency
+	lfu struct {
+		entries  map[string]*lfuEntry
+		heap     lfuHeap
+		tick     uint64
+		accesses int
+	}
+
+	lfuEntry struct {
+		key   string
+		freq  int
+		tick  uint64
+		index int
+	}
+
+	lfuHeap []*lfuEntry
+)
+
+// NewLFU creates the Policy evicting the least frequently used key, the
+// frequencies are aged to adapt to the changes of the popular keys
+func NewLFU() Policy {
+	return &lfu{entries: make(map[string]*lfuEntry)}
+}
+
+// Add tracks the new key with a single use
+func (l *lfu) Add(key string) {
+	l.tick++
+
+	e := &lfuEntry{key: key, freq: 1, tick: l.tick}
+	l.entries[key] = e
+	heap.Push(&l.heap, e)
+}
+
+// Access increments the frequency of the key
+func (l *lfu) Access(key string) {
+	e, ok := l.entries[key]
+	if !ok {
+		return
+	}
+
+	l.tick++
+	e.freq++
+	e.tick = l.tick
+	heap.Fix(&l.heap, e.index)
+
+	if l.accesses++; l.accesses >= lfuAging*len(l.entries) {
+		l.age()
+	}
+}
+
+// age halves the frequencies of all keys
+func (l *lfu) age() {
+	for _, e := range l.heap {
+		e.freq = (e.freq + 1) / 2
+	}
+
+	heap.Init(&l.heap)
+	l.accesses = 0
+}
+
+// Remove stops tracking the key
+func (l *lfu) Remove(key string) {
+	if e, ok := l.entries[key]; ok {
+		heap.Remove(&l.heap, e.index)
+		delete(l.entries, key)
+	}
+}
+
+// Evict removes and returns the least frequently used key
+func (l *lfu) Evict() (string, bool) {
+	if len(l.heap) == 0 {
+		return "", false
+	}
+
+	e := heap.Pop(&l.heap).(*lfuEntry)
+	delete(l.entries, e.key)
+
+	return e.key, true
+}
+
+func (h lfuHeap) Len() int {
+	return len(h)
+}
+
+func (h lfuHeap) Less(i, j int) bool {
+	if h[i].freq == h[j].freq {
+		return h[i].tick < h[j].tick
+	}
+
+	return h[i].freq < h[j].freq
+}
+
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap) Push(x any) {
+	e := x.(*lfuEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *lfuHeap) Pop() any {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+
+	return e
+}
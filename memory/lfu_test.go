@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLFU(t *testing.T) {
+	l := NewLFU()
+
+	l.Add("a")
+	l.Add("b")
+	l.Add("c")
+	l.Add("d")
+	l.Access("a")
+	l.Access("a")
+	l.Access("c")
+	l.Remove("d")
+
+	for _, expected := range []string{"b", "c", "a"} {
+		if key, _ := l.Evict(); key != expected {
+			t.Errorf("evict failed: expected %s, got %s", expected, key)
+		}
+	}
+
+	if _, ok := l.Evict(); ok {
+		t.Error("evict failed: expected no key to evict")
+	}
+}
+
+func TestLFUAging(t *testing.T) {
+	l := NewLFU().(*lfu)
+
+	l.Add("old")
+
+	for i := 0; i < 6; i++ {
+		l.Access("old")
+	}
+
+	for i := 0; i < 4; i++ {
+		l.Add(fmt.Sprint(i))
+	}
+
+	// the accesses to the new keys trigger the aging of the old one
+	for i := 0; i < lfuAging*len(l.entries); i++ {
+		l.Access(fmt.Sprint(i % 4))
+	}
+
+	if key, _ := l.Evict(); key != "old" {
+		t.Errorf("evict failed: expected the aged key %s, got %s", "old", key)
+	}
+}
@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"container/list"
+)
+
+// lru evicts the least recently used key
+type lru struct {
+	order    *list.List
+	elements map[string]*list.Element
+}
+
+// NewLRU creates the Policy evicting the least recently used key
+func NewLRU() Policy {
+	return &lru{list.New(), make(map[string]*list.Element)}
+}
+
+// Add tracks the new key as the most recently used
+func (l *lru) Add(key string) {
+	l.elements[key] = l.order.PushFront(key)
+}
+
+// Access marks the key as the most recently used
+func (l *lru) Access(key string) {
+	if e, ok := l.elements[key]; ok {
+		l.order.MoveToFront(e)
+	}
+}
+
+// Remove stops tracking the key
+func (l *lru) Remove(key string) {
+	if e, ok := l.elements[key]; ok {
+		l.order.Remove(e)
+		delete(l.elements, key)
+	}
+}
+
+// Evict removes and returns the least recently used key
+func (l *lru) Evict() (string, bool) {
+	e := l.order.Back()
+	if e == nil {
+		return "", false
+	}
+
+	key := e.Value.(string)
+	l.Remove(key)
+
+	return key, true
+}
@@ -0,0 +1,25 @@
+package memory
+
+import (
+	"testing"
+)
+
+func TestLRU(t *testing.T) {
+	l := NewLRU()
+
+	l.Add("a")
+	l.Add("b")
+	l.Add("c")
+	l.Access("a")
+	l.Remove("b")
+
+	for _, expected := range []string{"c", "a"} {
+		if key, _ := l.Evict(); key != expected {
+			t.Errorf("evict failed: expected %s, got %s", expected, key)
+		}
+	}
+
+	if _, ok := l.Evict(); ok {
+		t.Error("evict failed: expected no key to evict")
+	}
+}
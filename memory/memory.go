@@ -0,0 +1,372 @@
+// Package memory providers a bounded cache driver that stores the cache in
+// memory, evicting the keys chosen by the eviction policy once the limits are
+// reached, the least recently used ones by default.
+package memory
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/faabiosr/cachego"
+)
+
+type (
+	// item keeps the expiration and stale time in Unix nanoseconds
+	item struct {
+		data     []byte
+		duration int64
+		stale    int64
+	}
+
+	memory struct {
+		mu         sync.Mutex
+		items      map[string]*item
+		policy     Policy
+		size       int
+		maxEntries int
+		maxBytes   int
+		onEvict    func(key, value string)
+		clock      cachego.Clock
+		interval   time.Duration
+		janitor    *cachego.Janitor
+	}
+
+	// Option configures the Memory cache driver
+	Option func(*memory)
+
+	evicted struct {
+		key  string
+		data []byte
+	}
+)
+
+// New creates an instance of Memory cache driver, without limits the keys
+// are never evicted
+func New(opts ...Option) cachego.Cache {
+	m := &memory{
+		items:  make(map[string]*item),
+		policy: NewLRU(),
+		clock:  cachego.SystemClock{},
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if m.interval > 0 {
+		m.janitor = cachego.NewJanitor(m, m.interval)
+	}
+
+	return m
+}
+
+// WithMaxEntries sets the maximum number of cached keys
+func WithMaxEntries(n int) Option {
+	return func(m *memory) {
+		m.maxEntries = n
+	}
+}
+
+// WithMaxBytes sets the maximum size of the cached keys and values
+func WithMaxBytes(n int) Option {
+	return func(m *memory) {
+		m.maxBytes = n
+	}
+}
+
+// WithPolicy sets the policy choosing the keys to evict, the instance must
+// not be shared between caches
+func WithPolicy(policy Policy) Option {
+	return func(m *memory) {
+		m.policy = policy
+	}
+}
+
+// WithEvictionCallback sets the function called with the keys evicted to
+// respect the limits, it is not called for the deleted or expired keys
+func WithEvictionCallback(fn func(key, value string)) Option {
+	return func(m *memory) {
+		m.onEvict = fn
+	}
+}
+
+// WithClock sets the clock used to compute the expiration of the keys
+func WithClock(clock cachego.Clock) Option {
+	return func(m *memory) {
+		m.clock = clock
+	}
+}
+
+// WithJanitor purges the expired keys in the background every interval,
+// until the cache is closed
+func WithJanitor(interval time.Duration) Option {
+	return func(m *memory) {
+		m.interval = interval
+	}
+}
+
+func (m *memory) read(key string) (*item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	it, ok := m.items[key]
+	if !ok {
+		return nil, cachego.ErrCacheMiss
+	}
+
+	if it.duration > 0 && it.duration <= m.clock.Now().UnixNano() {
+		m.remove(key)
+		return nil, cachego.ErrCacheExpired
+	}
+
+	m.policy.Access(key)
+
+	return it, nil
+}
+
+// remove deletes the key, the lock must be held
+func (m *memory) remove(key string) {
+	if it, ok := m.items[key]; ok {
+		m.size -= len(key) + len(it.data)
+		delete(m.items, key)
+		m.policy.Remove(key)
+	}
+}
+
+// Close stops the janitor of the Memory storage
+func (m *memory) Close() error {
+	if m.janitor != nil {
+		m.janitor.Stop()
+	}
+
+	return nil
+}
+
+// Contains checks if cached key exists in Memory storage
+func (m *memory) Contains(key string) bool {
+	return m.ContainsContext(context.Background(), key)
+}
+
+// ContainsContext checks if cached key exists in Memory storage
+func (m *memory) ContainsContext(ctx context.Context, key string) bool {
+	_, err := m.FetchContext(ctx, key)
+	return err == nil
+}
+
+// Delete the cached key from Memory storage
+func (m *memory) Delete(key string) error {
+	return m.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext the cached key from Memory storage
+func (m *memory) DeleteContext(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(key)
+	return nil
+}
+
+// Fetch retrieves the cached value from key of the Memory storage
+func (m *memory) Fetch(key string) (string, error) {
+	return m.FetchContext(context.Background(), key)
+}
+
+// FetchContext retrieves the cached value from key of the Memory storage
+func (m *memory) FetchContext(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	it, err := m.read(key)
+	if err != nil {
+		return "", err
+	}
+
+	return string(it.data), nil
+}
+
+// FetchBytes retrieves the cached binary value from key of the Memory storage
+func (m *memory) FetchBytes(key string) ([]byte, error) {
+	it, err := m.read(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.Clone(it.data), nil
+}
+
+// FetchStale retrieves the cached value from key of the Memory storage and
+// whether it is stale
+func (m *memory) FetchStale(key string) (string, bool, error) {
+	it, err := m.read(key)
+	if err != nil {
+		return "", false, err
+	}
+
+	return string(it.data), it.stale > 0 && it.stale <= m.clock.Now().UnixNano(), nil
+}
+
+// FetchMulti retrieves multiple cached value from keys of the Memory storage
+func (m *memory) FetchMulti(keys []string) map[string]string {
+	return m.FetchMultiContext(context.Background(), keys)
+}
+
+// FetchMultiContext retrieves multiple cached value from keys of the Memory storage
+func (m *memory) FetchMultiContext(ctx context.Context, keys []string) map[string]string {
+	result := make(map[string]string)
+
+	for _, key := range keys {
+		if value, err := m.FetchContext(ctx, key); err == nil {
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+// FetchMultiBytes retrieves multiple cached binary value from keys of the Memory storage
+func (m *memory) FetchMultiBytes(keys []string) map[string][]byte {
+	result := make(map[string][]byte)
+
+	for _, key := range keys {
+		if value, err := m.FetchBytes(key); err == nil {
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+// Flush removes all cached keys of the Memory storage
+func (m *memory) Flush() error {
+	return m.FlushContext(context.Background())
+}
+
+// FlushContext removes all cached keys of the Memory storage
+func (m *memory) FlushContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key := range m.items {
+		m.remove(key)
+	}
+
+	return nil
+}
+
+// PurgeExpired removes the expired keys of the Memory storage
+func (m *memory) PurgeExpired() (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.clock.Now().UnixNano()
+	purged := 0
+
+	for key, it := range m.items {
+		if it.duration > 0 && it.duration <= now {
+			m.remove(key)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// Save a value in Memory storage by key
+func (m *memory) Save(key string, value string, lifeTime time.Duration) error {
+	return m.SaveContext(context.Background(), key, value, lifeTime)
+}
+
+// SaveContext a value in Memory storage by key
+func (m *memory) SaveContext(ctx context.Context, key string, value string, lifeTime time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return m.store(key, []byte(value), 0, lifeTime)
+}
+
+// SaveBytes a binary value in Memory storage by key
+func (m *memory) SaveBytes(key string, value []byte, lifeTime time.Duration) error {
+	return m.store(key, bytes.Clone(value), 0, lifeTime)
+}
+
+// SaveStale a value in Memory storage by key that becomes stale after the stale time
+func (m *memory) SaveStale(key string, value string, staleTime, lifeTime time.Duration) error {
+	return m.store(key, []byte(value), staleTime, lifeTime)
+}
+
+// store saves the value evicting the keys over the limits, a value larger
+// than the maximum size is not saved
+func (m *memory) store(key string, data []byte, staleTime, lifeTime time.Duration) error {
+	size := len(key) + len(data)
+
+	if m.maxBytes > 0 && size > m.maxBytes {
+		return cachego.ErrSave
+	}
+
+	now := m.clock.Now()
+	it := &item{data: data}
+
+	if lifeTime > 0 {
+		it.duration = now.Add(lifeTime).UnixNano()
+	}
+
+	if staleTime > 0 {
+		it.stale = now.Add(staleTime).UnixNano()
+	}
+
+	m.mu.Lock()
+
+	if old, ok := m.items[key]; ok {
+		m.size -= len(key) + len(old.data)
+		m.policy.Access(key)
+	} else {
+		m.policy.Add(key)
+	}
+
+	m.items[key] = it
+	m.size += size
+
+	evicted := m.evict()
+
+	m.mu.Unlock()
+
+	if m.onEvict != nil {
+		for _, e := range evicted {
+			m.onEvict(e.key, string(e.data))
+		}
+	}
+
+	return nil
+}
+
+// evict removes the keys over the limits, the lock must be held
+func (m *memory) evict() []evicted {
+	var result []evicted
+
+	for (m.maxEntries > 0 && len(m.items) > m.maxEntries) || (m.maxBytes > 0 && m.size > m.maxBytes) {
+		key, ok := m.policy.Evict()
+		if !ok {
+			break
+		}
+
+		if it, ok := m.items[key]; ok {
+			result = append(result, evicted{key, it.data})
+			m.remove(key)
+		}
+	}
+
+	return result
+}
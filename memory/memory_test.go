@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/faabiosr/cachego"
+	"github.com/faabiosr/cachego/cachegotest"
+	"github.com/faabiosr/cachego/chain"
+	"github.com/faabiosr/cachego/sync"
+)
+
+const (
+	testKey   = "foo"
+	testValue = "bar"
+)
+
+func TestMemory(t *testing.T) {
+	c := New()
+
+	if err := c.Save(testKey, testValue, 1*time.Nanosecond); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheExpired) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheExpired, err)
+	}
+
+	_ = c.Save(testKey, testValue, 10*time.Second)
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	if !c.Contains(testKey) {
+		t.Errorf("contains failed: the key %s should be exist", testKey)
+	}
+
+	if err := c.Flush(); err != nil {
+		t.Errorf("flush failed: expected nil, got %v", err)
+	}
+
+	if c.Contains(testKey) {
+		t.Errorf("contains failed: the key %s should not be exist", testKey)
+	}
+}
+
+func TestMemorySuite(t *testing.T) {
+	cachegotest.RunClockSuite(t, func(_ *testing.T, clock cachego.Clock) cachego.Cache {
+		return New(WithClock(clock), WithMaxEntries(100))
+	})
+}
+
+func TestMemoryMaxEntries(t *testing.T) {
+	var evicted []string
+
+	c := New(WithMaxEntries(2), WithEvictionCallback(func(key, value string) {
+		evicted = append(evicted, key+"="+value)
+	}))
+
+	_ = c.Save("a", "1", 0)
+	_ = c.Save("b", "2", 0)
+
+	if _, err := c.Fetch("a"); err != nil {
+		t.Errorf("fetch failed: expected nil, got %v", err)
+	}
+
+	_ = c.Save("c", "3", 0)
+
+	if c.Contains("b") {
+		t.Errorf("contains failed: the least recently used key %s should be evicted", "b")
+	}
+
+	if values := c.FetchMulti([]string{"a", "b", "c"}); len(values) != 2 {
+		t.Errorf("fetch multi failed: expected %d, got %d", 2, len(values))
+	}
+
+	if len(evicted) != 1 || evicted[0] != "b=2" {
+		t.Errorf("eviction callback failed: expected [b=2], got %v", evicted)
+	}
+
+	_ = c.Delete("a")
+	_ = c.Save("d", "4", 0)
+
+	if len(evicted) != 1 {
+		t.Errorf("eviction callback failed: expected no eviction under the limit, got %v", evicted)
+	}
+}
+
+func TestMemoryMaxBytes(t *testing.T) {
+	c := New(WithMaxBytes(10))
+
+	_ = c.Save("a", "1234", 0)
+	_ = c.Save("b", "1234", 0)
+
+	if !c.Contains("a") || !c.Contains("b") {
+		t.Errorf("contains failed: the keys %s and %s should be exist", "a", "b")
+	}
+
+	_ = c.Save("c", "12", 0)
+
+	if c.Contains("a") {
+		t.Errorf("contains failed: the key %s should be evicted", "a")
+	}
+
+	if err := c.Save("d", "1234567890", 0); !errors.Is(err, cachego.ErrSave) {
+		t.Errorf("save failed: expected %v, got %v", cachego.ErrSave, err)
+	}
+
+	if !c.Contains("b") || !c.Contains("c") {
+		t.Errorf("contains failed: the keys %s and %s should be exist", "b", "c")
+	}
+
+	_ = c.Save("b", "12345678", 0)
+
+	if c.Contains("c") {
+		t.Errorf("contains failed: the key %s should be evicted by the replaced value", "c")
+	}
+
+	if res, _ := c.Fetch("b"); res != "12345678" {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", "12345678", res)
+	}
+}
+
+func TestMemoryChain(t *testing.T) {
+	l2 := sync.New()
+	c := chain.New(New(WithMaxEntries(10)), l2)
+
+	for i := 0; i < 20; i++ {
+		_ = c.Save(fmt.Sprintf("key-%d", i), testValue, 0)
+	}
+
+	if values := c.FetchMulti([]string{"key-0", "key-19"}); len(values) != 2 {
+		t.Errorf("fetch multi failed: expected %d, got %d", 2, len(values))
+	}
+
+	if !l2.Contains("key-0") {
+		t.Errorf("contains failed: the key %s should be exist in the second level", "key-0")
+	}
+}
@@ -0,0 +1,17 @@
+package memory
+
+// Policy chooses the keys evicted once the limits of the cache are reached,
+// the cache calls it holding its lock
+type Policy interface {
+	// Add tracks a key saved in the cache
+	Add(key string)
+
+	// Access marks a cached key as used
+	Access(key string)
+
+	// Remove stops tracking a key deleted from the cache
+	Remove(key string)
+
+	// Evict removes and returns the next key to evict
+	Evict() (string, bool)
+}
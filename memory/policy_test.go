@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/faabiosr/cachego"
+)
+
+const (
+	traceSize  = 100000
+	traceKeys  = 10000
+	traceCache = 500
+)
+
+var policies = []struct {
+	name   string
+	policy func() Policy
+}{
+	{"lru", NewLRU},
+	{"lfu", NewLFU},
+	{"arc", func() Policy { return NewARC(traceCache) }},
+}
+
+// zipfTrace generates keys following a Zipf distribution, with a scan of
+// keys used once every scan accesses when scan is greater than zero
+func zipfTrace(scan int) []string {
+	r := rand.New(rand.NewSource(42))
+	z := rand.NewZipf(r, 1.1, 1, traceKeys-1)
+	trace := make([]string, 0, traceSize)
+
+	for i := 0; len(trace) < traceSize; i++ {
+		if scan > 0 && i%scan == 0 {
+			for j := 0; j < traceCache; j++ {
+				trace = append(trace, fmt.Sprintf("scan-%d-%d", i, j))
+			}
+		}
+
+		trace = append(trace, fmt.Sprint(z.Uint64()))
+	}
+
+	return trace
+}
+
+// hitRatio replays the trace saving every missing key
+func hitRatio(c cachego.Cache, trace []string) float64 {
+	hits := 0
+
+	for _, key := range trace {
+		if _, err := c.Fetch(key); err == nil {
+			hits++
+			continue
+		}
+
+		_ = c.Save(key, key, 0)
+	}
+
+	return float64(hits) / float64(len(trace))
+}
+
+func TestPolicyScan(t *testing.T) {
+	trace := zipfTrace(1000)
+	lru := hitRatio(New(WithMaxEntries(traceCache)), trace)
+
+	for _, p := range policies[1:] {
+		c := New(WithMaxEntries(traceCache), WithPolicy(p.policy()))
+
+		if ratio := hitRatio(c, trace); ratio <= lru {
+			t.Errorf("%s hit ratio failed: expected more than %.4f, got %.4f", p.name, lru, ratio)
+		}
+	}
+}
+
+func BenchmarkHitRatio(b *testing.B) {
+	traces := []struct {
+		name  string
+		trace []string
+	}{
+		{"zipf", zipfTrace(0)},
+		{"zipf+scan", zipfTrace(1000)},
+	}
+
+	for _, tr := range traces {
+		for _, p := range policies {
+			b.Run(fmt.Sprintf("%s/%s", tr.name, p.name), func(b *testing.B) {
+				var ratio float64
+
+				for i := 0; i < b.N; i++ {
+					c := New(WithMaxEntries(traceCache), WithPolicy(p.policy()))
+					ratio = hitRatio(c, tr.trace)
+				}
+
+				b.ReportMetric(ratio*100, "hit%")
+			})
+		}
+	}
+}
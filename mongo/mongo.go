@@ -3,6 +3,10 @@ package mongo
 
 import (
 	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/faabiosr/cachego"
@@ -14,102 +18,513 @@ import (
 type (
 	mongoCache struct {
 		collection *mongo.Collection
+		prefix     string
+		clock      cachego.Clock
+		interval   time.Duration
+		janitor    *cachego.Janitor
 	}
 
+	// Option configures the Mongo cache driver
+	Option func(*mongoCache)
+
+	// mongoContent keeps the expiration and stale time in Unix nanoseconds,
+	// Duration is the expiration in Unix seconds of the previous releases,
+	// Version is replaced by every write
 	mongoContent struct {
-		Duration int64
-		Key      string `bson:"_id"`
-		Value    string
+		Duration  int64
+		Key       string `bson:"_id"`
+		Value     []byte
+		ExpiresAt int64         `bson:",omitempty"`
+		StaleAt   int64         `bson:",omitempty"`
+		Version   bson.ObjectID `bson:",omitempty"`
 	}
 )
 
 // New creates an instance of Mongo cache driver
-func New(collection *mongo.Collection) cachego.Cache {
-	return &mongoCache{collection}
+func New(collection *mongo.Collection, opts ...Option) cachego.Cache {
+	m := &mongoCache{collection: collection, clock: cachego.SystemClock{}}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if m.interval > 0 {
+		m.janitor = cachego.NewJanitor(m, m.interval)
+	}
+
+	return m
 }
 
 // NewMongoDriver alias for New.
-func NewMongoDriver(collection *mongo.Collection) cachego.Cache {
-	return New(collection)
+func NewMongoDriver(collection *mongo.Collection, opts ...Option) cachego.Cache {
+	return New(collection, opts...)
+}
+
+// WithClock sets the clock used to compute the expiration of the keys
+func WithClock(clock cachego.Clock) Option {
+	return func(m *mongoCache) {
+		m.clock = clock
+	}
+}
+
+// WithJanitor purges the expired keys in the background every interval,
+// until the cache is closed
+func WithJanitor(interval time.Duration) Option {
+	return func(m *mongoCache) {
+		m.interval = interval
+	}
 }
 
+// migrate converts the Unix seconds of the content saved by the previous
+// releases
+func (c *mongoContent) migrate() {
+	if c.ExpiresAt == 0 && c.Duration > 0 {
+		c.ExpiresAt = time.Unix(c.Duration, 0).UnixNano()
+	}
+}
+
+// Close stops the janitor of the Mongo storage, the collection is not closed
+func (m *mongoCache) Close() error {
+	if m.janitor != nil {
+		m.janitor.Stop()
+	}
+
+	return nil
+}
+
+// Contains checks if cached key exists in Mongo storage
 func (m *mongoCache) Contains(key string) bool {
-	_, err := m.Fetch(key)
+	return m.ContainsContext(context.Background(), key)
+}
+
+// ContainsContext checks if cached key exists in Mongo storage
+func (m *mongoCache) ContainsContext(ctx context.Context, key string) bool {
+	_, err := m.FetchContext(ctx, key)
 	return err == nil
 }
 
 // Delete the cached key from Mongo storage
 func (m *mongoCache) Delete(key string) error {
-	_, err := m.collection.DeleteOne(context.TODO(), bson.M{"_id": bson.M{"$eq": key}})
+	return m.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext the cached key from Mongo storage
+func (m *mongoCache) DeleteContext(ctx context.Context, key string) error {
+	_, err := m.collection.DeleteOne(ctx, bson.M{"_id": bson.M{"$eq": m.prefix + key}})
 	return err
 }
 
+// DeleteMulti removes multiple cached keys from Mongo storage at once
+func (m *mongoCache) DeleteMulti(keys []string) error {
+	_, err := m.collection.DeleteMany(context.Background(), bson.M{"_id": bson.M{"$in": m.keys(keys)}})
+	return err
+}
+
+// keys returns the prefixed keys
+func (m *mongoCache) keys(keys []string) []string {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = m.prefix + key
+	}
+
+	return prefixed
+}
+
 // Fetch retrieves the cached value from key of the Mongo storage
 func (m *mongoCache) Fetch(key string) (string, error) {
+	return m.FetchContext(context.Background(), key)
+}
+
+// FetchContext retrieves the cached value from key of the Mongo storage
+func (m *mongoCache) FetchContext(ctx context.Context, key string) (string, error) {
+	content, err := m.read(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	return string(content.Value), nil
+}
+
+// FetchBytes retrieves the cached binary value from key of the Mongo storage
+func (m *mongoCache) FetchBytes(key string) ([]byte, error) {
+	content, err := m.read(context.Background(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	return content.Value, nil
+}
+
+// FetchStale retrieves the cached value from key of the Mongo storage and
+// whether it is stale
+func (m *mongoCache) FetchStale(key string) (string, bool, error) {
+	content, err := m.read(context.Background(), key)
+	if err != nil {
+		return "", false, err
+	}
+
+	return string(content.Value), content.StaleAt > 0 && content.StaleAt <= m.clock.Now().UnixNano(), nil
+}
+
+func (m *mongoCache) read(ctx context.Context, key string) (*mongoContent, error) {
 	content := &mongoContent{}
-	result := m.collection.FindOne(context.TODO(), bson.M{"_id": bson.M{"$eq": key}})
-	if result == nil {
-		return "", cachego.ErrCacheExpired
+	result := m.collection.FindOne(ctx, bson.M{"_id": bson.M{"$eq": m.prefix + key}})
+	if result == nil || errors.Is(result.Err(), mongo.ErrNoDocuments) {
+		return nil, cachego.ErrCacheMiss
 	}
 	if result.Err() != nil {
-		return "", result.Err()
+		return nil, result.Err()
 	}
 
 	err := result.Decode(&content)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	if content.Duration == 0 {
-		return content.Value, nil
+
+	content.migrate()
+
+	if content.ExpiresAt == 0 {
+		return content, nil
 	}
 
-	if content.Duration <= time.Now().Unix() {
-		_ = m.Delete(key)
-		return "", cachego.ErrCacheExpired
+	if content.ExpiresAt <= m.clock.Now().UnixNano() {
+		_ = m.DeleteContext(ctx, key)
+		return nil, cachego.ErrCacheExpired
 	}
-	return content.Value, nil
+	return content, nil
 }
 
+// FetchWithVersion retrieves the cached value from key of the Mongo storage
+// and its version
+func (m *mongoCache) FetchWithVersion(key string) (string, string, error) {
+	content, err := m.read(context.Background(), key)
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(content.Value), content.Version.Hex(), nil
+}
+
+// FetchMulti retrieves multiple cached value from keys of the Mongo storage
 func (m *mongoCache) FetchMulti(keys []string) map[string]string {
+	return m.FetchMultiContext(context.Background(), keys)
+}
+
+// FetchMultiContext retrieves multiple cached value from keys of the Mongo storage
+func (m *mongoCache) FetchMultiContext(ctx context.Context, keys []string) map[string]string {
 	result := make(map[string]string)
 
-	cur, err := m.collection.Find(context.TODO(), bson.M{"_id": bson.M{"$in": keys}})
+	for key, value := range m.readMulti(ctx, keys) {
+		result[key] = string(value)
+	}
+
+	return result
+}
+
+// FetchMultiBytes retrieves multiple cached binary value from keys of the Mongo storage
+func (m *mongoCache) FetchMultiBytes(keys []string) map[string][]byte {
+	return m.readMulti(context.Background(), keys)
+}
+
+func (m *mongoCache) readMulti(ctx context.Context, keys []string) map[string][]byte {
+	result := make(map[string][]byte)
+
+	cur, err := m.collection.Find(ctx, bson.M{"_id": bson.M{"$in": m.keys(keys)}})
 	if err != nil {
 		return result
 	}
 	defer func() {
-		_ = cur.Close(context.Background())
+		_ = cur.Close(ctx)
 	}()
 
-	content := &mongoContent{}
+	now := m.clock.Now().UnixNano()
 
-	for cur.Next(context.Background()) {
-		err := cur.Decode(content)
-		if err != nil {
+	for cur.Next(ctx) {
+		content := &mongoContent{}
+
+		if err := cur.Decode(content); err != nil {
+			continue
+		}
+
+		content.migrate()
+
+		if content.ExpiresAt > 0 && content.ExpiresAt <= now {
 			continue
 		}
 
-		result[content.Key] = content.Value
+		result[strings.TrimPrefix(content.Key, m.prefix)] = content.Value
 	}
 	return result
 }
 
 // Flush removes all cached keys of the Mongo storage
 func (m *mongoCache) Flush() error {
-	_, err := m.collection.DeleteMany(context.TODO(), bson.M{})
+	return m.FlushContext(context.Background())
+}
+
+// FlushContext removes all cached keys of the Mongo storage
+func (m *mongoCache) FlushContext(ctx context.Context) error {
+	filter := bson.M{}
+
+	if m.prefix != "" {
+		filter = bson.M{"_id": bson.M{"$regex": "^" + regexp.QuoteMeta(m.prefix)}}
+	}
+
+	_, err := m.collection.DeleteMany(ctx, filter)
 	return err
 }
 
+// Namespace returns a view of the Mongo storage that prefixes all the keys
+// by the prefix and a colon, sharing the collection without its janitor
+func (m *mongoCache) Namespace(prefix string) cachego.Cache {
+	return &mongoCache{collection: m.collection, prefix: m.prefix + prefix + ":", clock: m.clock}
+}
+
+// PurgeExpired removes the expired keys of the Mongo storage
+func (m *mongoCache) PurgeExpired() (int, error) {
+	now := m.clock.Now()
+
+	result, err := m.collection.DeleteMany(context.Background(), expired(now))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(result.DeletedCount), nil
+}
+
+// expired returns the filter of the keys expired at the time, including the
+// ones saved by previous versions
+func expired(now time.Time) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"expiresat": bson.M{"$gt": 0, "$lte": now.UnixNano()}},
+		bson.M{"expiresat": bson.M{"$exists": false}, "duration": bson.M{"$gt": 0, "$lte": now.Unix()}},
+	}}
+}
+
 // Save a value in Mongo storage by key
 func (m *mongoCache) Save(key string, value string, lifeTime time.Duration) error {
-	duration := int64(0)
+	return m.SaveContext(context.Background(), key, value, lifeTime)
+}
 
-	if lifeTime > 0 {
-		duration = time.Now().Unix() + int64(lifeTime.Seconds())
+// SaveContext a value in Mongo storage by key
+func (m *mongoCache) SaveContext(ctx context.Context, key string, value string, lifeTime time.Duration) error {
+	return m.write(ctx, key, []byte(value), 0, lifeTime)
+}
+
+// SaveBytes a binary value in Mongo storage by key
+func (m *mongoCache) SaveBytes(key string, value []byte, lifeTime time.Duration) error {
+	return m.write(context.Background(), key, value, 0, lifeTime)
+}
+
+// SaveStale a value in Mongo storage by key that becomes stale after the stale time
+func (m *mongoCache) SaveStale(key string, value string, staleTime, lifeTime time.Duration) error {
+	return m.write(context.Background(), key, []byte(value), staleTime, lifeTime)
+}
+
+// SaveMulti multiple values in Mongo storage by their keys in a single bulk write
+func (m *mongoCache) SaveMulti(values map[string]string, lifeTime time.Duration) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(values))
+
+	for key, value := range values {
+		content := m.content(key, []byte(value), 0, lifeTime)
+
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": bson.M{"$eq": content.Key}}).
+			SetReplacement(content).
+			SetUpsert(true))
+	}
+
+	_, err := m.collection.BulkWrite(context.Background(), models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// SaveIfVersion a value in Mongo storage by key while its version is still
+// the version, the missing keys are inserted when the version is empty
+func (m *mongoCache) SaveIfVersion(key, value, version string, lifeTime time.Duration) error {
+	ctx := context.Background()
+	content := m.content(key, []byte(value), 0, lifeTime)
+	now := m.clock.Now()
+
+	if version == "" {
+		// an unexpired key fails the insert of the upsert by its duplicate _id
+		filter := expired(now)
+		filter["_id"] = bson.M{"$eq": content.Key}
+
+		_, err := m.collection.ReplaceOne(ctx, filter, content, options.Replace().SetUpsert(true))
+		if mongo.IsDuplicateKeyError(err) {
+			return cachego.ErrVersionMismatch
+		}
+
+		return err
 	}
 
-	content := &mongoContent{duration, key, value}
+	id, err := bson.ObjectIDFromHex(version)
+	if err != nil {
+		return cachego.ErrVersionMismatch
+	}
+
+	ok, err := m.replace(ctx, m.versionFilter(content.Key, id, now), content)
+	if err == nil && !ok {
+		return cachego.ErrVersionMismatch
+	}
+
+	return err
+}
+
+// DeleteIfVersion removes the cached key from Mongo storage while its version
+// is still the version
+func (m *mongoCache) DeleteIfVersion(key, version string) error {
+	id, err := bson.ObjectIDFromHex(version)
+	if err != nil {
+		return cachego.ErrVersionMismatch
+	}
+
+	filter := m.versionFilter(m.prefix+key, id, m.clock.Now())
+
+	result, err := m.collection.DeleteOne(context.Background(), filter)
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return cachego.ErrVersionMismatch
+	}
+
+	return nil
+}
+
+// versionFilter returns the filter of the unexpired key having the version
+func (m *mongoCache) versionFilter(key string, id bson.ObjectID, now time.Time) bson.M {
+	filter := bson.M{"_id": bson.M{"$eq": key}, "$nor": bson.A{expired(now)}, "version": id}
+
+	// the keys saved by previous versions have no version
+	if id.IsZero() {
+		filter["version"] = bson.M{"$exists": false}
+	}
+
+	return filter
+}
+
+// replace replaces the key matching the filter, returning whether it matched
+func (m *mongoCache) replace(ctx context.Context, filter bson.M, content *mongoContent) (bool, error) {
+	result, err := m.collection.ReplaceOne(ctx, filter, content)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+// SaveIfAbsent a value in Mongo storage by key only when the key is missing
+func (m *mongoCache) SaveIfAbsent(key string, value string, lifeTime time.Duration) (bool, error) {
+	err := m.SaveIfVersion(key, value, "", lifeTime)
+	if errors.Is(err, cachego.ErrVersionMismatch) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// Replace a value in Mongo storage by key only when the key exists
+func (m *mongoCache) Replace(key string, value string, lifeTime time.Duration) (bool, error) {
+	content := m.content(key, []byte(value), 0, lifeTime)
+	filter := bson.M{"_id": bson.M{"$eq": content.Key}, "$nor": bson.A{expired(m.clock.Now())}}
+
+	return m.replace(context.Background(), filter, content)
+}
+
+// Touch updates the life time of the cached key in Mongo storage
+func (m *mongoCache) Touch(key string, lifeTime time.Duration) error {
+	touched := m.content(key, nil, 0, lifeTime)
+	filter := bson.M{"_id": bson.M{"$eq": touched.Key}, "$nor": bson.A{expired(m.clock.Now())}}
+	update := bson.M{"$set": bson.M{"expiresat": touched.ExpiresAt, "duration": touched.Duration}}
+
+	result, err := m.collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return cachego.ErrCacheMiss
+	}
+
+	return nil
+}
+
+// Incr increments the value of the cached key in Mongo storage, replacing it
+// while its version is unchanged and keeping its life time
+func (m *mongoCache) Incr(key string, delta int64) (int64, error) {
+	ctx := context.Background()
+
+	for {
+		content, err := m.read(ctx, key)
+		if errors.Is(err, cachego.ErrCacheMiss) {
+			ok, err := m.SaveIfAbsent(key, strconv.FormatInt(delta, 10), 0)
+			if err != nil {
+				return 0, err
+			}
+
+			if ok {
+				return delta, nil
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		n, err := strconv.ParseInt(string(content.Value), 10, 64)
+		if err != nil {
+			return 0, cachego.ErrNotInteger
+		}
+
+		filter := m.versionFilter(content.Key, content.Version, m.clock.Now())
+		content.Value = []byte(strconv.FormatInt(n+delta, 10))
+		content.Version = bson.NewObjectID()
+
+		ok, err := m.replace(ctx, filter, content)
+		if err != nil {
+			return 0, err
+		}
+
+		if ok {
+			return n + delta, nil
+		}
+	}
+}
+
+// Decr decrements the value of the cached key in Mongo storage, keeping its
+// life time
+func (m *mongoCache) Decr(key string, delta int64) (int64, error) {
+	return m.Incr(key, -delta)
+}
+
+func (m *mongoCache) write(ctx context.Context, key string, value []byte, staleTime, lifeTime time.Duration) error {
+	content := m.content(key, value, staleTime, lifeTime)
+
 	opts := options.Replace().SetUpsert(true)
-	_, err := m.collection.ReplaceOne(context.TODO(), bson.M{"_id": bson.M{"$eq": key}}, content, opts)
+	_, err := m.collection.ReplaceOne(ctx, bson.M{"_id": bson.M{"$eq": content.Key}}, content, opts)
 	return err
 }
+
+func (m *mongoCache) content(key string, value []byte, staleTime, lifeTime time.Duration) *mongoContent {
+	now := m.clock.Now()
+	content := &mongoContent{Key: m.prefix + key, Value: value, Version: bson.NewObjectID()}
+
+	if lifeTime > 0 {
+		content.ExpiresAt = now.Add(lifeTime).UnixNano()
+		content.Duration = (content.ExpiresAt + int64(time.Second) - 1) / int64(time.Second)
+	}
+
+	if staleTime > 0 {
+		content.StaleAt = now.Add(staleTime).UnixNano()
+	}
+
+	return content
+}
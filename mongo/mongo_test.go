@@ -1,22 +1,34 @@
 package mongo
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"net"
 	"testing"
 	"time"
 
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/faabiosr/cachego"
+	"github.com/faabiosr/cachego/cachegotest"
 )
 
 const (
 	testKeyMongo   = "foo1"
 	testValueMongo = "bar"
+	testAddress    = "localhost:27017"
 )
 
 func TestMongo(t *testing.T) {
+	if _, err := net.Dial("tcp", testAddress); err != nil {
+		t.Skip(err)
+	}
+
 	// Set client options
-	clientOptions := options.Client().ApplyURI("mongodb://localhost:27017")
+	clientOptions := options.Client().ApplyURI("mongodb://" + testAddress)
 
 	// Connect to MongoDB
 	client, err := mongo.Connect(clientOptions)
@@ -62,3 +74,152 @@ func TestMongo(t *testing.T) {
 		t.Errorf("contains failed: the key %s should not be exist", testKeyMongo)
 	}
 }
+
+func TestMongoContext(t *testing.T) {
+	if _, err := net.Dial("tcp", testAddress); err != nil {
+		t.Skip(err)
+	}
+
+	client, err := mongo.Connect(options.Client().ApplyURI("mongodb://" + testAddress))
+	if err != nil {
+		t.Skip(err)
+	}
+
+	c := New(client.Database("cache").Collection("cache")).(cachego.ContextCache)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if err := c.SaveContext(ctx, testKeyMongo, testValueMongo, 0); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.FetchContext(ctx, testKeyMongo); res != testValueMongo {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValueMongo, res)
+	}
+
+	cancel()
+
+	if err := c.SaveContext(ctx, testKeyMongo, testValueMongo, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("save failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if _, err := c.FetchContext(ctx, testKeyMongo); !errors.Is(err, context.Canceled) {
+		t.Errorf("fetch failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if err := c.FlushContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("flush failed: expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestMongoBytes(t *testing.T) {
+	if _, err := net.Dial("tcp", testAddress); err != nil {
+		t.Skip(err)
+	}
+
+	client, err := mongo.Connect(options.Client().ApplyURI("mongodb://" + testAddress))
+	if err != nil {
+		t.Skip(err)
+	}
+
+	c := New(client.Database("cache").Collection("cache")).(cachego.BytesCache)
+	value := []byte{0x00, 0xff, 0xfe, '"', '\\'}
+
+	if err := c.SaveBytes(testKeyMongo, value, 10*time.Second); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.FetchBytes(testKeyMongo); !bytes.Equal(res, value) {
+		t.Errorf("fetch fail, wrong value: expected %v, got %v", value, res)
+	}
+
+	if values := c.FetchMultiBytes([]string{testKeyMongo, "baz"}); !bytes.Equal(values[testKeyMongo], value) {
+		t.Errorf("fetch multi failed, wrong value: expected %v, got %v", value, values[testKeyMongo])
+	}
+}
+
+func TestMongoCacheMiss(t *testing.T) {
+	if _, err := net.Dial("tcp", testAddress); err != nil {
+		t.Skip(err)
+	}
+
+	client, err := mongo.Connect(options.Client().ApplyURI("mongodb://" + testAddress))
+	if err != nil {
+		t.Skip(err)
+	}
+
+	c := New(client.Database("cache").Collection("cache"))
+	_ = c.Delete(testKeyMongo)
+
+	if _, err := c.Fetch(testKeyMongo); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+
+	if _, err := c.(cachego.BytesCache).FetchBytes(testKeyMongo); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+
+	_ = c.Save(testKeyMongo, testValueMongo, 1*time.Nanosecond)
+
+	if _, err := c.Fetch(testKeyMongo); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+}
+
+func TestMongoStale(t *testing.T) {
+	if _, err := net.Dial("tcp", testAddress); err != nil {
+		t.Skip(err)
+	}
+
+	client, err := mongo.Connect(options.Client().ApplyURI("mongodb://" + testAddress))
+	if err != nil {
+		t.Skip(err)
+	}
+
+	c := New(client.Database("cache").Collection("cache")).(cachego.StaleCache)
+
+	if err := c.SaveStale(testKeyMongo, testValueMongo, 1*time.Nanosecond, 10*time.Second); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, stale, _ := c.FetchStale(testKeyMongo); res != testValueMongo || !stale {
+		t.Errorf("fetch stale fail: expected a stale %s, got %s (stale %v)", testValueMongo, res, stale)
+	}
+
+	_ = c.SaveStale(testKeyMongo, testValueMongo, 10*time.Second, 0)
+
+	if _, stale, _ := c.FetchStale(testKeyMongo); stale {
+		t.Errorf("fetch stale fail: the key %s should not be stale", testKeyMongo)
+	}
+}
+
+func TestMongoSuite(t *testing.T) {
+	if _, err := net.Dial("tcp", testAddress); err != nil {
+		t.Skip(err)
+	}
+
+	cachegotest.RunClockSuite(t, func(t *testing.T, clock cachego.Clock) cachego.Cache {
+		client, err := mongo.Connect(options.Client().ApplyURI("mongodb://" + testAddress))
+		if err != nil {
+			t.Skip(err)
+		}
+
+		return New(client.Database("cache").Collection("cache"), WithClock(clock))
+	})
+}
+
+func TestMongoContentMigration(t *testing.T) {
+	content := &mongoContent{Duration: 1700000010}
+	content.migrate()
+
+	if expected := time.Unix(1700000010, 0).UnixNano(); content.ExpiresAt != expected {
+		t.Errorf("migrate failed, wrong expiration: expected %d, got %d", expected, content.ExpiresAt)
+	}
+
+	content = &mongoContent{Duration: 1700000010, ExpiresAt: 1700000009500000000}
+	content.migrate()
+
+	if content.ExpiresAt != 1700000009500000000 {
+		t.Errorf("migrate failed, wrong expiration: expected %d, got %d", int64(1700000009500000000), content.ExpiresAt)
+	}
+}
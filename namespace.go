@@ -0,0 +1,172 @@
+package cachego
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// generationPrefix prefixes the keys storing the generation of the namespaces
+const generationPrefix = "cachego:ns:"
+
+const generationSize = 8
+
+// generations serializes the creation of the generations in the caches not
+// implementing AtomicCache, only within the process
+var generations sync.Mutex
+
+type namespace struct {
+	cache  Cache
+	prefix string
+}
+
+// Namespace returns a view of the cache that prefixes all the keys, its Flush
+// only removes the keys of the namespace. When the cache implements Namespacer
+// its own view is returned, otherwise the keys are also prefixed by a
+// generation that Flush replaces, leaving the keys of the previous
+// generations to expire or to be evicted.
+//
+// The generation is created by the first Save of the namespace, atomically
+// when the cache implements AtomicCache. Other caches only serialize its
+// creation within the process, so the concurrent first saves of several
+// processes may create distinct generations and lose the keys saved with
+// the replaced one.
+func Namespace(cache Cache, prefix string) Cache {
+	if c, ok := cache.(Namespacer); ok {
+		return c.Namespace(prefix)
+	}
+
+	return &namespace{cache, prefix}
+}
+
+// generation retrieves the current generation of the namespace, creating a
+// new one when missing and create is set
+func (n *namespace) generation(create bool) (string, error) {
+	key := generationPrefix + n.prefix
+
+	gen, err := n.cache.Fetch(key)
+	if err == nil || !create {
+		return gen, err
+	}
+
+	if c, ok := n.cache.(AtomicCache); ok {
+		return n.createGeneration(c)
+	}
+
+	generations.Lock()
+	defer generations.Unlock()
+
+	if gen, err := n.cache.Fetch(key); err == nil {
+		return gen, nil
+	}
+
+	if gen, err = newGeneration(); err != nil {
+		return "", err
+	}
+
+	return gen, n.cache.Save(key, gen, 0)
+}
+
+// createGeneration saves a new generation unless another one was saved
+// meanwhile, returning the one kept by the cache
+func (n *namespace) createGeneration(c AtomicCache) (string, error) {
+	key := generationPrefix + n.prefix
+
+	gen, err := newGeneration()
+	if err != nil {
+		return "", err
+	}
+
+	saved, err := c.SaveIfAbsent(key, gen, 0)
+	if err != nil {
+		return "", err
+	}
+
+	if saved {
+		return gen, nil
+	}
+
+	return c.Fetch(key)
+}
+
+func newGeneration() (string, error) {
+	b := make([]byte, generationSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func (n *namespace) key(gen, key string) string {
+	return n.prefix + gen + ":" + key
+}
+
+// Contains checks if the cached key exists in the namespace
+func (n *namespace) Contains(key string) bool {
+	_, err := n.Fetch(key)
+	return err == nil
+}
+
+// Delete the cached key from the namespace
+func (n *namespace) Delete(key string) error {
+	gen, err := n.generation(false)
+	if errors.Is(err, ErrCacheMiss) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return n.cache.Delete(n.key(gen, key))
+}
+
+// Fetch retrieves the cached value from key of the namespace
+func (n *namespace) Fetch(key string) (string, error) {
+	gen, err := n.generation(false)
+	if err != nil {
+		return "", err
+	}
+
+	return n.cache.Fetch(n.key(gen, key))
+}
+
+// FetchMulti retrieves multiple cached value from keys of the namespace
+func (n *namespace) FetchMulti(keys []string) map[string]string {
+	result := make(map[string]string)
+
+	gen, err := n.generation(false)
+	if err != nil {
+		return result
+	}
+
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = n.key(gen, key)
+	}
+
+	for key, value := range n.cache.FetchMulti(prefixed) {
+		result[strings.TrimPrefix(key, n.key(gen, ""))] = value
+	}
+
+	return result
+}
+
+// Flush removes all cached keys of the namespace, by dropping its generation
+func (n *namespace) Flush() error {
+	return n.cache.Delete(generationPrefix + n.prefix)
+}
+
+// Save a value in the namespace by key
+func (n *namespace) Save(key string, value string, lifeTime time.Duration) error {
+	gen, err := n.generation(true)
+	if err != nil {
+		return err
+	}
+
+	return n.cache.Save(n.key(gen, key), value, lifeTime)
+}
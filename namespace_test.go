@@ -0,0 +1,88 @@
+package cachego
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNamespace(t *testing.T) {
+	cache := mapCache{}
+	users := Namespace(cache, "users:")
+	posts := Namespace(cache, "posts:")
+
+	if err := users.Save("foo", "bar", 0); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	_ = users.Save("baz", "qux", 0)
+	_ = posts.Save("foo", "posts", 0)
+
+	for key := range cache {
+		if !strings.HasPrefix(key, "users:") && !strings.HasPrefix(key, "posts:") && !strings.HasPrefix(key, generationPrefix) {
+			t.Errorf("save failed: expected the key %s to be prefixed", key)
+		}
+	}
+
+	if res, _ := users.Fetch("foo"); res != "bar" {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", "bar", res)
+	}
+
+	if res, _ := posts.Fetch("foo"); res != "posts" {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", "posts", res)
+	}
+
+	if values := users.FetchMulti([]string{"foo", "baz", "bar"}); len(values) != 2 || values["baz"] != "qux" {
+		t.Errorf("fetch multi failed: expected %d, got %v", 2, values)
+	}
+
+	if err := users.Delete("baz"); err != nil {
+		t.Errorf("delete failed: expected nil, got %v", err)
+	}
+
+	if users.Contains("baz") {
+		t.Errorf("contains failed: the key %s should not be exist", "baz")
+	}
+
+	if err := users.Flush(); err != nil {
+		t.Errorf("flush failed: expected nil, got %v", err)
+	}
+
+	if _, err := users.Fetch("foo"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", ErrCacheMiss, err)
+	}
+
+	if err := users.Delete("foo"); err != nil {
+		t.Errorf("delete failed: expected nil, got %v", err)
+	}
+
+	if values := users.FetchMulti([]string{"foo"}); len(values) != 0 {
+		t.Errorf("fetch multi failed: expected %d, got %d", 0, len(values))
+	}
+
+	if !posts.Contains("foo") {
+		t.Errorf("contains failed: the key %s should be exist", "foo")
+	}
+
+	_ = users.Save("foo", "bar", 0)
+
+	if res, _ := users.Fetch("foo"); res != "bar" {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", "bar", res)
+	}
+}
+
+type namespacerCache struct {
+	mapCache
+}
+
+func (c namespacerCache) Namespace(string) Cache {
+	return c.mapCache
+}
+
+func TestNamespaceNamespacer(t *testing.T) {
+	cache := namespacerCache{mapCache{}}
+
+	if _, ok := Namespace(cache, "users:").(mapCache); !ok {
+		t.Error("namespace failed: expected the view of the cache")
+	}
+}
@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/faabiosr/cachego"
+)
+
+// TokenBucket allows the events while its bucket has tokens, a token is added
+// every interval up to the capacity. The bucket keeps the time it is full
+// again, replaced by compare-and-swap, and expires once it is full.
+type TokenBucket struct {
+	config
+	store    cachego.CASCache
+	capacity int64
+	interval time.Duration
+}
+
+// NewTokenBucket creates an instance of TokenBucket, the capacity and the
+// interval must be positive
+func NewTokenBucket(store cachego.CASCache, capacity int64, interval time.Duration, opts ...Option) (*TokenBucket, error) {
+	if err := validate(capacity, interval); err != nil {
+		return nil, err
+	}
+
+	return &TokenBucket{config: newConfig(opts), store: store, capacity: capacity, interval: interval}, nil
+}
+
+// Allow reports whether an event of the key takes a token of its bucket
+func (b *TokenBucket) Allow(key string) (Result, error) {
+	return b.AllowN(key, 1)
+}
+
+// AllowN reports whether n events of the key take n tokens of its bucket, the
+// denied events take no tokens
+func (b *TokenBucket) AllowN(key string, n int64) (Result, error) {
+	if n <= 0 {
+		return Result{}, errEvents
+	}
+
+	key = b.prefix + key
+
+	for {
+		now := b.clock.Now().UnixNano()
+
+		full, version, err := b.full(key, now)
+		if err != nil {
+			return Result{}, err
+		}
+
+		// the bucket is empty when it is full again after the capacity
+		capacity := b.capacity * int64(b.interval)
+		next := full + n*int64(b.interval)
+
+		if next-now > capacity {
+			return Result{
+				Remaining:  (capacity - (full - now)) / int64(b.interval),
+				RetryAfter: time.Duration(next - now - capacity),
+			}, nil
+		}
+
+		err = b.store.SaveIfVersion(key, strconv.FormatInt(next, 10), version, time.Duration(next-now))
+		if errors.Is(err, cachego.ErrVersionMismatch) {
+			continue
+		}
+
+		if err != nil {
+			return Result{}, err
+		}
+
+		return Result{Allowed: true, Remaining: (capacity - (next - now)) / int64(b.interval)}, nil
+	}
+}
+
+// full returns the Unix nanoseconds the bucket of the key is full again, now
+// at the latest, and the version of the key
+func (b *TokenBucket) full(key string, now int64) (int64, string, error) {
+	value, version, err := b.store.FetchWithVersion(key)
+	if errors.Is(err, cachego.ErrCacheMiss) {
+		return now, "", nil
+	}
+
+	if err != nil {
+		return 0, "", err
+	}
+
+	full, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, "", cachego.ErrNotInteger
+	}
+
+	return max(full, now), version, nil
+}
@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	s, clock := newStore()
+	l, _ := NewTokenBucket(s, 3, time.Second, WithClock(clock))
+
+	res, err := l.AllowN(testKey, 2)
+	assertResult(t, res, err, Result{Allowed: true, Remaining: 1})
+
+	res, err = l.AllowN(testKey, 2)
+	assertResult(t, res, err, Result{Remaining: 1, RetryAfter: time.Second})
+
+	res, err = l.Allow(testKey)
+	assertResult(t, res, err, Result{Allowed: true, Remaining: 0})
+
+	res, err = l.Allow(testKey)
+	assertResult(t, res, err, Result{Remaining: 0, RetryAfter: time.Second})
+
+	clock.Advance(1500 * time.Millisecond)
+
+	res, err = l.Allow(testKey)
+	assertResult(t, res, err, Result{Allowed: true, Remaining: 0})
+
+	res, err = l.Allow(testKey)
+	assertResult(t, res, err, Result{Remaining: 0, RetryAfter: 500 * time.Millisecond})
+
+	res, err = l.AllowN("bar", 4)
+	assertResult(t, res, err, Result{Remaining: 3, RetryAfter: time.Second})
+}
+
+func TestTokenBucketRefill(t *testing.T) {
+	s, clock := newStore()
+	l, _ := NewTokenBucket(s, 3, time.Second, WithClock(clock))
+
+	_, _ = l.AllowN(testKey, 3)
+
+	// the bucket expires once it is full, it never holds more than its
+	// capacity
+	clock.Advance(time.Minute)
+
+	if s.Contains("cachego:ratelimit:" + testKey) {
+		t.Errorf("allow failed: the full bucket should be expired")
+	}
+
+	res, err := l.AllowN(testKey, 3)
+	assertResult(t, res, err, Result{Allowed: true, Remaining: 0})
+}
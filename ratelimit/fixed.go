@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/faabiosr/cachego"
+)
+
+// FixedWindow allows a limit of events in each window of time, the counter
+// of a window expires along with it
+type FixedWindow struct {
+	config
+	store  cachego.AtomicCache
+	limit  int64
+	window time.Duration
+}
+
+// NewFixedWindow creates an instance of FixedWindow, the limit and the window
+// must be positive
+func NewFixedWindow(store cachego.AtomicCache, limit int64, window time.Duration, opts ...Option) (*FixedWindow, error) {
+	if err := validate(limit, window); err != nil {
+		return nil, err
+	}
+
+	return &FixedWindow{config: newConfig(opts), store: store, limit: limit, window: window}, nil
+}
+
+// Allow reports whether an event of the key is allowed in the current window
+func (f *FixedWindow) Allow(key string) (Result, error) {
+	return f.AllowN(key, 1)
+}
+
+// AllowN reports whether n events of the key are allowed in the current
+// window, the denied events are not counted
+func (f *FixedWindow) AllowN(key string, n int64) (Result, error) {
+	if n <= 0 {
+		return Result{}, errEvents
+	}
+
+	now := f.clock.Now()
+	window := now.UnixNano() / int64(f.window)
+	end := time.Unix(0, (window+1)*int64(f.window)).Sub(now)
+	key = f.windowKey(key, window)
+
+	counted, err := incr(f.store, key, n, end)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if counted <= f.limit {
+		return Result{Allowed: true, Remaining: f.limit - counted}, nil
+	}
+
+	if err := decr(f.store, key, n); err != nil {
+		return Result{}, err
+	}
+
+	return Result{Remaining: max(f.limit-counted+n, 0), RetryAfter: end}, nil
+}
@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFixedWindow(t *testing.T) {
+	s, clock := newStore()
+	l, _ := NewFixedWindow(s, 3, time.Minute, WithClock(clock))
+
+	clock.Advance(15 * time.Second)
+
+	res, err := l.Allow(testKey)
+	assertResult(t, res, err, Result{Allowed: true, Remaining: 2})
+
+	res, err = l.AllowN(testKey, 3)
+	assertResult(t, res, err, Result{Remaining: 2, RetryAfter: 45 * time.Second})
+
+	res, err = l.AllowN(testKey, 2)
+	assertResult(t, res, err, Result{Allowed: true, Remaining: 0})
+
+	res, err = l.Allow(testKey)
+	assertResult(t, res, err, Result{Remaining: 0, RetryAfter: 45 * time.Second})
+
+	res, err = l.Allow("bar")
+	assertResult(t, res, err, Result{Allowed: true, Remaining: 2})
+
+	clock.Advance(45 * time.Second)
+
+	res, err = l.Allow(testKey)
+	assertResult(t, res, err, Result{Allowed: true, Remaining: 2})
+}
+
+func TestFixedWindowExpiration(t *testing.T) {
+	s, clock := newStore()
+	l, _ := NewFixedWindow(s, 3, time.Minute, WithClock(clock), WithPrefix("limits:"))
+
+	clock.Advance(50 * time.Second)
+
+	_, _ = l.Allow(testKey)
+
+	if !s.Contains("limits:" + testKey + ":60000") {
+		t.Fatalf("allow failed: the counter of the window should be exist")
+	}
+
+	clock.Advance(10 * time.Second)
+
+	if s.Contains("limits:" + testKey + ":60000") {
+		t.Errorf("allow failed: the counter should be expired with the window")
+	}
+}
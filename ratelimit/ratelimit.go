@@ -0,0 +1,158 @@
+// Package ratelimit provides rate limiters keeping their state in the cachego
+// drivers, so that every instance of a service shares the same limits.
+//
+// The fixed and sliding window limiters count the events by the atomic
+// increments of cachego.AtomicCache, the token bucket keeps the time its
+// bucket is full again by the compare-and-swap of cachego.CASCache.
+package ratelimit
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/faabiosr/cachego"
+)
+
+type (
+	// Limiter limits the events of the keys
+	Limiter interface {
+		// Allow reports whether an event of the key is allowed
+		Allow(key string) (Result, error)
+
+		// AllowN reports whether n events of the key are allowed at once,
+		// the denied events are not counted
+		AllowN(key string, n int64) (Result, error)
+	}
+
+	// Result is the decision of a limiter
+	Result struct {
+		// Allowed reports whether the events are allowed
+		Allowed bool
+
+		// Remaining is the number of events still allowed right away
+		Remaining int64
+
+		// RetryAfter is the time to wait before the denied events may be
+		// allowed, zero when they are allowed
+		RetryAfter time.Duration
+	}
+
+	// Option configures the limiters
+	Option func(*config)
+
+	config struct {
+		prefix string
+		clock  cachego.Clock
+	}
+)
+
+const defaultPrefix = "cachego:ratelimit:"
+
+var (
+	errEvents = errors.New("ratelimit: the number of events must be positive")
+	errLimit  = errors.New("ratelimit: the limit must be positive")
+	errPeriod = errors.New("ratelimit: the window or interval must be positive")
+)
+
+func newConfig(opts []Option) config {
+	c := config{prefix: defaultPrefix, clock: cachego.SystemClock{}}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return c
+}
+
+// validate checks the limit and the period of time of a limiter
+func validate(limit int64, period time.Duration) error {
+	if limit <= 0 {
+		return errLimit
+	}
+
+	if period <= 0 {
+		return errPeriod
+	}
+
+	return nil
+}
+
+// WithPrefix sets the prefix of the keys keeping the state of the limiter,
+// "cachego:ratelimit:" by default
+func WithPrefix(prefix string) Option {
+	return func(c *config) {
+		c.prefix = prefix
+	}
+}
+
+// WithClock sets the clock used to compute the windows and the refills
+func WithClock(clock cachego.Clock) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
+
+// windowKey returns the key counting the events of the key in the window
+func (c config) windowKey(key string, window int64) string {
+	return c.prefix + key + ":" + strconv.FormatInt(window, 10)
+}
+
+// count returns the counter kept in the store, zero when it is missing
+func count(store cachego.Cache, key string) (int64, error) {
+	value, err := store.Fetch(key)
+	if errors.Is(err, cachego.ErrCacheMiss) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, cachego.ErrNotInteger
+	}
+
+	return n, nil
+}
+
+// incr counts the events in the store, the counter is created along with its
+// life time so that it expires even when the increment fails
+func incr(store cachego.AtomicCache, key string, n int64, lifeTime time.Duration) (int64, error) {
+	created, err := store.SaveIfAbsent(key, "0", lifeTime)
+	if err != nil {
+		return 0, err
+	}
+
+	counted, err := store.Incr(key, n)
+	if err != nil {
+		return 0, err
+	}
+
+	// the counter expired before the increment, which created it again
+	// without a life time
+	if !created && counted == n {
+		if err := store.Touch(key, lifeTime); err != nil {
+			_ = store.Delete(key)
+			return 0, err
+		}
+	}
+
+	return counted, nil
+}
+
+// decr uncounts the denied events, removing the counter created again without
+// a life time when it expired after their increment
+func decr(store cachego.AtomicCache, key string, n int64) error {
+	counted, err := store.Decr(key, n)
+	if err != nil {
+		return err
+	}
+
+	if counted < 0 {
+		return store.Delete(key)
+	}
+
+	return nil
+}
@@ -0,0 +1,264 @@
+package ratelimit
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	_ "github.com/mattn/go-sqlite3"
+	rd "github.com/redis/go-redis/v9"
+	bt "go.etcd.io/bbolt"
+
+	"github.com/faabiosr/cachego"
+	"github.com/faabiosr/cachego/bolt"
+	"github.com/faabiosr/cachego/cachegotest"
+	"github.com/faabiosr/cachego/redis"
+	"github.com/faabiosr/cachego/sqlite3"
+	syncmap "github.com/faabiosr/cachego/sync"
+)
+
+const (
+	testKey = "foo"
+
+	workers    = 8
+	iterations = 20
+	limit      = 50
+)
+
+type (
+	// store is the cache keeping the state of every limiter
+	store interface {
+		cachego.AtomicCache
+		cachego.CASCache
+	}
+
+	// factory creates the limiter under test on the store
+	factory func(s store, limit int64, period time.Duration, opts ...Option) (Limiter, error)
+
+	// expiredStore behaves as if its counters expired right after they are
+	// created, and fails to set their life time
+	expiredStore struct {
+		store
+	}
+)
+
+var errTouch = errors.New("touch failed")
+
+func (expiredStore) SaveIfAbsent(string, string, time.Duration) (bool, error) {
+	return false, nil
+}
+
+func (expiredStore) Touch(string, time.Duration) error {
+	return errTouch
+}
+
+// start is the time of the fake clocks, at the start of a minute
+var start = time.Unix(0, 0).Add(1000 * time.Hour)
+
+// factories creates every limiter
+var factories = map[string]factory{
+	"FixedWindow": func(s store, limit int64, window time.Duration, opts ...Option) (Limiter, error) {
+		return NewFixedWindow(s, limit, window, opts...)
+	},
+	"SlidingWindow": func(s store, limit int64, window time.Duration, opts ...Option) (Limiter, error) {
+		return NewSlidingWindow(s, limit, window, opts...)
+	},
+	"TokenBucket": func(s store, capacity int64, interval time.Duration, opts ...Option) (Limiter, error) {
+		return NewTokenBucket(s, capacity, interval, opts...)
+	},
+}
+
+// newStore creates an in-memory store sharing the clock with the limiter
+func newStore() (store, *cachegotest.Clock) {
+	clock := cachegotest.NewClock(start)
+	return syncmap.New(syncmap.WithClock(clock)).(store), clock
+}
+
+// assertResult checks the decision of the limiter
+func assertResult(t *testing.T, res Result, err error, expected Result) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("allow failed: expected nil, got %v", err)
+	}
+
+	if res != expected {
+		t.Errorf("allow failed, wrong result: expected %+v, got %+v", expected, res)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	s, _ := newStore()
+
+	for name, newLimiter := range factories {
+		l, _ := newLimiter(s, limit, time.Minute)
+
+		if _, err := l.AllowN(testKey, 0); !errors.Is(err, errEvents) {
+			t.Errorf("%s allow failed: expected %v, got %v", name, errEvents, err)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	s, _ := newStore()
+
+	cases := []struct {
+		limit    int64
+		period   time.Duration
+		expected error
+	}{
+		{0, time.Minute, errLimit},
+		{-1, time.Minute, errLimit},
+		{limit, 0, errPeriod},
+		{limit, -time.Second, errPeriod},
+	}
+
+	for name, newLimiter := range factories {
+		for _, c := range cases {
+			if _, err := newLimiter(s, c.limit, c.period); !errors.Is(err, c.expected) {
+				t.Errorf("%s create failed: expected %v for %d events in %v, got %v", name, c.expected, c.limit, c.period, err)
+			}
+		}
+	}
+}
+
+func TestCounterLifeTime(t *testing.T) {
+	s, clock := newStore()
+
+	if _, err := incr(s, testKey, 2, time.Minute); err != nil {
+		t.Fatalf("incr failed: expected nil, got %v", err)
+	}
+
+	clock.Advance(time.Minute)
+
+	if s.Contains(testKey) {
+		t.Errorf("incr failed: the counter %s should be expired", testKey)
+	}
+
+	if _, err := incr(expiredStore{s}, testKey, 2, time.Minute); !errors.Is(err, errTouch) {
+		t.Errorf("incr failed: expected %v, got %v", errTouch, err)
+	}
+
+	if s.Contains(testKey) {
+		t.Errorf("incr failed: the counter %s without a life time should be removed", testKey)
+	}
+
+	if err := decr(s, testKey, 2); err != nil {
+		t.Errorf("decr failed: expected nil, got %v", err)
+	}
+
+	if s.Contains(testKey) {
+		t.Errorf("decr failed: the counter %s without a life time should be removed", testKey)
+	}
+}
+
+func TestNotInteger(t *testing.T) {
+	s, _ := newStore()
+
+	_ = s.Save("cachego:ratelimit:"+testKey, testKey, 0)
+	_ = s.Save("cachego:ratelimit:"+testKey+":"+"59999", testKey, 0)
+
+	tb, _ := NewTokenBucket(s, limit, time.Second)
+	if _, err := tb.Allow(testKey); !errors.Is(err, cachego.ErrNotInteger) {
+		t.Errorf("allow failed: expected %v, got %v", cachego.ErrNotInteger, err)
+	}
+
+	sw, _ := NewSlidingWindow(s, limit, time.Minute, WithClock(cachegotest.NewClock(start)))
+	if _, err := sw.Allow(testKey); !errors.Is(err, cachego.ErrNotInteger) {
+		t.Errorf("allow failed: expected %v, got %v", cachego.ErrNotInteger, err)
+	}
+}
+
+func TestLimitersContention(t *testing.T) {
+	stores := map[string]func(t *testing.T) store{
+		"sync": func(*testing.T) store {
+			return syncmap.New().(store)
+		},
+		"bolt": func(t *testing.T) store {
+			db, err := bt.Open(filepath.Join(t.TempDir(), "cache.db"), 0o600, nil)
+			if err != nil {
+				t.Skip(err)
+			}
+
+			t.Cleanup(func() { _ = db.Close() })
+
+			return bolt.New(db).(store)
+		},
+		"sqlite3": func(t *testing.T) store {
+			db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "cache.db"))
+			if err != nil {
+				t.Skip(err)
+			}
+
+			t.Cleanup(func() { _ = db.Close() })
+
+			c, err := sqlite3.New(db, "cache")
+			if err != nil {
+				t.Skip(err)
+			}
+
+			return c.(store)
+		},
+		"redis": func(t *testing.T) store {
+			conn := rd.NewClient(&rd.Options{Addr: miniredis.RunT(t).Addr()})
+			t.Cleanup(func() { _ = conn.Close() })
+
+			return redis.New(conn).(store)
+		},
+	}
+
+	for name, newStore := range stores {
+		for limiter, newLimiter := range factories {
+			t.Run(name+"/"+limiter, func(t *testing.T) {
+				clock := cachegotest.NewClock(start.Add(time.Second))
+
+				l, err := newLimiter(newStore(t), limit, time.Minute, WithClock(clock))
+				if err != nil {
+					t.Fatalf("create failed: expected nil, got %v", err)
+				}
+
+				testContention(t, l)
+			})
+		}
+	}
+}
+
+// testContention sends more events than the limit from several workers,
+// exactly the limit of them must be allowed
+func testContention(t *testing.T, l Limiter) {
+	var (
+		allowed atomic.Int64
+		wg      sync.WaitGroup
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < iterations; i++ {
+				res, err := l.Allow(testKey)
+				if err != nil {
+					t.Errorf("allow failed: expected nil, got %v", err)
+					return
+				}
+
+				if res.Allowed {
+					allowed.Add(1)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if n := allowed.Load(); n != limit {
+		t.Errorf("allow failed: expected %d events allowed, got %d", limit, n)
+	}
+}
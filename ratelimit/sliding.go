@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/faabiosr/cachego"
+)
+
+// SlidingWindow allows a limit of events in any window of time, estimating
+// the events of the window ending now from the counters of the current and
+// the previous fixed windows, the previous one weighted by its overlap
+type SlidingWindow struct {
+	config
+	store  cachego.AtomicCache
+	limit  int64
+	window time.Duration
+}
+
+// NewSlidingWindow creates an instance of SlidingWindow, the limit and the
+// window must be positive
+func NewSlidingWindow(store cachego.AtomicCache, limit int64, window time.Duration, opts ...Option) (*SlidingWindow, error) {
+	if err := validate(limit, window); err != nil {
+		return nil, err
+	}
+
+	return &SlidingWindow{config: newConfig(opts), store: store, limit: limit, window: window}, nil
+}
+
+// Allow reports whether an event of the key is allowed in the window ending
+// now
+func (s *SlidingWindow) Allow(key string) (Result, error) {
+	return s.AllowN(key, 1)
+}
+
+// AllowN reports whether n events of the key are allowed in the window ending
+// now, the denied events are not counted
+func (s *SlidingWindow) AllowN(key string, n int64) (Result, error) {
+	if n <= 0 {
+		return Result{}, errEvents
+	}
+
+	now := s.clock.Now().UnixNano()
+	window := now / int64(s.window)
+	elapsed := time.Duration(now - window*int64(s.window))
+	current := s.windowKey(key, window)
+
+	// the counter is read as the previous one during the next window
+	counted, err := incr(s.store, current, n, 2*s.window-elapsed)
+	if err != nil {
+		return Result{}, err
+	}
+
+	previous, err := count(s.store, s.windowKey(key, window-1))
+	if err != nil {
+		return Result{}, err
+	}
+
+	overlap := float64(s.window-elapsed) / float64(s.window)
+	estimated := int64(float64(previous)*overlap) + counted
+
+	if estimated <= s.limit {
+		return Result{Allowed: true, Remaining: s.limit - estimated}, nil
+	}
+
+	if err := decr(s.store, current, n); err != nil {
+		return Result{}, err
+	}
+
+	return Result{Remaining: max(s.limit-estimated+n, 0), RetryAfter: s.retryAfter(previous, counted, elapsed)}, nil
+}
+
+// retryAfter returns the time until the weight of the previous window leaves
+// room for the events counted in the current one, or the end of the current
+// window when they are over the limit on their own
+func (s *SlidingWindow) retryAfter(previous, counted int64, elapsed time.Duration) time.Duration {
+	if counted > s.limit || previous == 0 {
+		return s.window - elapsed
+	}
+
+	// the overlap allowing the events is (limit - counted) / previous
+	allowed := time.Duration(float64(s.window) * float64(s.limit-counted) / float64(previous))
+
+	return max(s.window-allowed-elapsed, time.Nanosecond)
+}
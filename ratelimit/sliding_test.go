@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlidingWindow(t *testing.T) {
+	s, clock := newStore()
+	l, _ := NewSlidingWindow(s, 10, time.Minute, WithClock(clock))
+
+	res, err := l.AllowN(testKey, 10)
+	assertResult(t, res, err, Result{Allowed: true, Remaining: 0})
+
+	res, err = l.Allow(testKey)
+	assertResult(t, res, err, Result{Remaining: 0, RetryAfter: time.Minute})
+
+	// half of the previous window overlaps the window ending now
+	clock.Advance(90 * time.Second)
+
+	res, err = l.AllowN(testKey, 5)
+	assertResult(t, res, err, Result{Allowed: true, Remaining: 0})
+
+	res, err = l.Allow(testKey)
+	assertResult(t, res, err, Result{Remaining: 0, RetryAfter: 6 * time.Second})
+
+	clock.Advance(6 * time.Second)
+
+	res, err = l.Allow(testKey)
+	assertResult(t, res, err, Result{Allowed: true, Remaining: 0})
+
+	res, err = l.AllowN(testKey, 5)
+	assertResult(t, res, err, Result{Remaining: 0, RetryAfter: 24 * time.Second})
+}
+
+func TestSlidingWindowExpiration(t *testing.T) {
+	s, clock := newStore()
+	l, _ := NewSlidingWindow(s, 10, time.Minute, WithClock(clock))
+
+	_, _ = l.AllowN(testKey, 10)
+
+	// the counter is kept while it is the previous window
+	clock.Advance(119 * time.Second)
+
+	if !s.Contains("cachego:ratelimit:" + testKey + ":60000") {
+		t.Fatalf("allow failed: the counter of the previous window should be exist")
+	}
+
+	clock.Advance(time.Second)
+
+	if s.Contains("cachego:ratelimit:" + testKey + ":60000") {
+		t.Errorf("allow failed: the counter should be expired after the next window")
+	}
+
+	res, err := l.AllowN(testKey, 10)
+	assertResult(t, res, err, Result{Allowed: true, Remaining: 0})
+}
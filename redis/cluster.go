@@ -0,0 +1,68 @@
+package redis
+
+import "strings"
+
+const (
+	// clusterSlots is the number of hash slots of a Redis Cluster
+	clusterSlots = 16384
+
+	// crc16Poly is the polynomial of the CRC16-CCITT (XMODEM) used by the
+	// cluster to hash the keys
+	crc16Poly = 0x1021
+	crc16MSB  = 0x8000
+	byteBits  = 8
+)
+
+// crc16 returns the CRC16-CCITT (XMODEM) checksum of s
+func crc16(s string) uint16 {
+	var crc uint16
+
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << byteBits
+
+		for j := 0; j < byteBits; j++ {
+			if crc&crc16MSB != 0 {
+				crc = crc<<1 ^ crc16Poly
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
+
+// slot returns the cluster hash slot of the key, when the key has a non-empty
+// hash tag between its first braces only the tag is hashed
+func slot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+
+	return int(crc16(key) % clusterSlots)
+}
+
+// groupBySlot groups the keys by their hash slot, so that the commands taking
+// several keys never cross the slots of a cluster
+func groupBySlot(keys []string) [][]string {
+	var groups [][]string
+
+	slots := make(map[int]int)
+
+	for _, key := range keys {
+		s := slot(key)
+
+		g, ok := slots[s]
+		if !ok {
+			g = len(groups)
+			slots[s] = g
+			groups = append(groups, nil)
+		}
+
+		groups[g] = append(groups[g], key)
+	}
+
+	return groups
+}
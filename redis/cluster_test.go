@@ -0,0 +1,168 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	rd "github.com/redis/go-redis/v9"
+
+	"github.com/faabiosr/cachego"
+	"github.com/faabiosr/cachego/cachegotest"
+)
+
+func TestSlot(t *testing.T) {
+	tests := map[string]int{
+		"123456789":             12739,
+		"foo":                   12182,
+		"bar":                   5061,
+		"{user1000}.following":  3443,
+		"{user1000}.followers":  3443,
+		"foo{}{bar}":            slot("foo{}{bar}"),
+		"foo{{bar}}zap":         slot("{bar"),
+		"foo{bar}{zap}":         slot("bar"),
+		"{}":                    slot("{}"),
+		"prefix:{tag}:anything": slot("tag"),
+	}
+
+	for key, expected := range tests {
+		if s := slot(key); s != expected {
+			t.Errorf("slot failed, wrong value of %s: expected %d, got %d", key, expected, s)
+		}
+	}
+
+	if s := slot("foo{}{bar}"); s == slot("bar") {
+		t.Errorf("slot failed: the empty hash tag of %s should be ignored", "foo{}{bar}")
+	}
+}
+
+func TestGroupBySlot(t *testing.T) {
+	groups := groupBySlot([]string{"foo", "{foo}:a", "bar", "{foo}:b", "{bar}"})
+
+	if len(groups) != 2 {
+		t.Fatalf("group failed: expected %d groups, got %v", 2, groups)
+	}
+
+	if fmt.Sprint(groups) != "[[foo {foo}:a {foo}:b] [bar {bar}]]" {
+		t.Errorf("group failed, wrong value: got %v", groups)
+	}
+}
+
+// runMiniredis starts a miniredis server whose keys expire in real time, as
+// miniredis only expires the keys when its time is moved forward
+func runMiniredis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+
+	m := miniredis.RunT(t)
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				m.FastForward(10 * time.Millisecond)
+			}
+		}
+	}()
+
+	return m
+}
+
+// cluster starts a cluster stand-in of three miniredis nodes, each serving a
+// range of the hash slots
+func cluster(t *testing.T) (*rd.ClusterClient, []*miniredis.Miniredis) {
+	t.Helper()
+
+	nodes := make([]*miniredis.Miniredis, 3)
+	slots := make([]rd.ClusterSlot, len(nodes))
+	size := clusterSlots / len(nodes)
+
+	for i := range nodes {
+		nodes[i] = runMiniredis(t)
+
+		slots[i] = rd.ClusterSlot{
+			Start: i * size,
+			End:   (i+1)*size - 1,
+			Nodes: []rd.ClusterNode{{Addr: nodes[i].Addr()}},
+		}
+	}
+
+	slots[len(slots)-1].End = clusterSlots - 1
+
+	conn := rd.NewClusterClient(&rd.ClusterOptions{
+		ClusterSlots: func(context.Context) ([]rd.ClusterSlot, error) {
+			return slots, nil
+		},
+	})
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn, nodes
+}
+
+func TestRedisClusterSlots(t *testing.T) {
+	conn, nodes := cluster(t)
+	c := New(conn, WithPrefix("cachego:"))
+
+	keys := make([]string, 30)
+	values := make(map[string]string, len(keys))
+
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+		values[keys[i]] = fmt.Sprintf("value-%d", i)
+	}
+
+	if err := cachego.NewBatchCache(c).SaveMulti(values, 0); err != nil {
+		t.Fatalf("save multi fail: expected nil, got %v", err)
+	}
+
+	for i, node := range nodes {
+		if len(node.Keys()) == 0 {
+			t.Errorf("save multi failed: expected keys on the node %d", i)
+		}
+	}
+
+	result := c.FetchMulti(append(keys, "missing"))
+	if len(result) != len(keys) {
+		t.Errorf("fetch multi failed: expected %d, got %d", len(keys), len(result))
+	}
+
+	for key, value := range values {
+		if result[key] != value {
+			t.Errorf("fetch multi failed, wrong value: expected %s, got %s", value, result[key])
+		}
+	}
+
+	if err := cachego.NewBatchCache(c).DeleteMulti(keys[:10]); err != nil {
+		t.Errorf("delete multi failed: expected nil, got %v", err)
+	}
+
+	if result := c.FetchMulti(keys); len(result) != len(keys)-10 {
+		t.Errorf("delete multi failed: expected %d, got %d", len(keys)-10, len(result))
+	}
+
+	if err := c.Flush(); err != nil {
+		t.Errorf("flush failed: expected nil, got %v", err)
+	}
+
+	for i, node := range nodes {
+		if keys := node.Keys(); len(keys) != 0 {
+			t.Errorf("flush failed: expected no keys on the node %d, got %v", i, keys)
+		}
+	}
+}
+
+func TestRedisClusterSuite(t *testing.T) {
+	cachegotest.RunSuite(t, func(t *testing.T) cachego.Cache {
+		conn, _ := cluster(t)
+		return New(conn)
+	})
+}
@@ -0,0 +1,524 @@
+package redis
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	rd "github.com/redis/go-redis/v9"
+
+	"github.com/faabiosr/cachego"
+)
+
+// expiryEnvelope prefixes the values with a life time when the server does
+// not support HPEXPIRE, it is followed by the big-endian expiration in Unix
+// nanoseconds
+const expiryEnvelope = "\xffcachego:expires:"
+
+const expiryHeader = len(expiryEnvelope) + 8
+
+// watchRetries is the number of attempts of a conditional write while the
+// other fields of the hash change
+const watchRetries = 16
+
+var errContention = errors.New("redis: the hash kept changing during the transaction")
+
+// the support of HPEXPIRE, checked on the first save with a life time
+const (
+	expireUnknown int32 = iota
+	expireNative
+	expireEnvelope
+)
+
+type hash struct {
+	driver rd.Cmdable
+	prefix string
+	name   string
+	expire *atomic.Int32
+}
+
+// key returns the key of the hash storing the cached keys as its fields
+func (h *hash) key() string {
+	return h.prefix + h.name
+}
+
+// expiry checks if the server supports HPEXPIRE, the result is kept once known
+func (h *hash) expiry(ctx context.Context) (int32, error) {
+	if expire := h.expire.Load(); expire != expireUnknown {
+		return expire, nil
+	}
+
+	// the probe field does not exist, so a supporting server changes nothing
+	err := h.driver.HPExpire(ctx, h.key(), time.Second, expiryEnvelope).Err()
+
+	switch {
+	case err == nil:
+		h.expire.Store(expireNative)
+	case strings.Contains(strings.ToLower(err.Error()), "unknown command"):
+		h.expire.Store(expireEnvelope)
+	default:
+		return expireUnknown, err
+	}
+
+	return h.expire.Load(), nil
+}
+
+// openExpiry unwraps the value from its envelope, returning its expiration
+func openExpiry(value []byte) ([]byte, int64) {
+	if len(value) < expiryHeader || string(value[:len(expiryEnvelope)]) != expiryEnvelope {
+		return value, 0
+	}
+
+	expiresAt := int64(binary.BigEndian.Uint64(value[len(expiryEnvelope):expiryHeader]))
+
+	return value[expiryHeader:], expiresAt
+}
+
+func (h *hash) read(ctx context.Context, key string) ([]byte, error) {
+	_, value, err := h.field(ctx, h.driver, key)
+	return value, err
+}
+
+// field reads the stored field of the key and its value out of the envelope,
+// an expired field is deleted
+func (h *hash) field(ctx context.Context, cmd rd.Cmdable, key string) (string, []byte, error) {
+	raw, err := cmd.HGet(ctx, h.key(), key).Result()
+	if err != nil {
+		return "", nil, cacheErr(err)
+	}
+
+	value, expiresAt := openExpiry([]byte(raw))
+
+	if expiresAt > 0 && expiresAt <= time.Now().UnixNano() {
+		_ = h.DeleteContext(ctx, key)
+		return "", nil, cachego.ErrCacheExpired
+	}
+
+	return raw, value, nil
+}
+
+// mode returns how the fields expire after the life time
+func (h *hash) mode(ctx context.Context, lifeTime time.Duration) (int32, error) {
+	if lifeTime <= 0 {
+		return expireNative, nil
+	}
+
+	return h.expiry(ctx)
+}
+
+// write sets the fields of the values, expiring them after the life time
+func (h *hash) write(ctx context.Context, values map[string][]byte, lifeTime time.Duration) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	expire, err := h.mode(ctx, lifeTime)
+	if err != nil {
+		return err
+	}
+
+	if lifeTime <= 0 || expire == expireEnvelope {
+		return h.driver.HSet(ctx, h.key(), h.fields(values, lifeTime, expire)...).Err()
+	}
+
+	_, err = h.driver.TxPipelined(ctx, func(pipe rd.Pipeliner) error {
+		h.set(ctx, pipe, values, lifeTime, expire)
+		return nil
+	})
+
+	return err
+}
+
+// set queues the commands setting the fields of the values
+func (h *hash) set(ctx context.Context, pipe rd.Pipeliner, values map[string][]byte, lifeTime time.Duration, expire int32) {
+	pipe.HSet(ctx, h.key(), h.fields(values, lifeTime, expire)...)
+
+	if lifeTime <= 0 || expire == expireEnvelope {
+		return
+	}
+
+	keys := make([]string, 0, len(values))
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	pipe.HPExpire(ctx, h.key(), lifeTime, keys...)
+}
+
+// fields returns the fields and values of HSET, the values are wrapped in an
+// envelope with their expiration when the server does not support HPEXPIRE
+func (h *hash) fields(values map[string][]byte, lifeTime time.Duration, expire int32) []any {
+	fields := make([]any, 0, 2*len(values))
+	expiresAt := uint64(time.Now().Add(lifeTime).UnixNano())
+
+	for key, value := range values {
+		if lifeTime > 0 && expire == expireEnvelope {
+			value = wrapExpiry(value, expiresAt)
+		}
+
+		fields = append(fields, key, value)
+	}
+
+	return fields
+}
+
+// wrapExpiry wraps the value in an envelope with its expiration
+func wrapExpiry(value []byte, expiresAt uint64) []byte {
+	data := make([]byte, 0, expiryHeader+len(value))
+	data = append(data, expiryEnvelope...)
+	data = binary.BigEndian.AppendUint64(data, expiresAt)
+
+	return append(data, value...)
+}
+
+// watch runs the commands returned by fn in a transaction while the field of
+// the key is unchanged, fn receives the stored field and whether it exists
+// and returns no commands to leave it as is. The hash is watched as a whole,
+// so the transaction is retried a few times when the other fields changed.
+func (h *hash) watch(ctx context.Context, key string, fn func(raw string, ok bool) (func(rd.Pipeliner), error)) error {
+	w, ok := h.driver.(watcher)
+	if !ok {
+		return errWatch
+	}
+
+	for i := 0; i < watchRetries; i++ {
+		err := w.Watch(ctx, func(tx *rd.Tx) error {
+			raw, _, err := h.field(ctx, tx, key)
+
+			missing := errors.Is(err, cachego.ErrCacheMiss)
+			if err != nil && !missing {
+				return err
+			}
+
+			queue, err := fn(raw, !missing)
+			if err != nil || queue == nil {
+				return err
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe rd.Pipeliner) error {
+				queue(pipe)
+				return nil
+			})
+
+			return err
+		}, h.key())
+
+		if !errors.Is(err, rd.TxFailedErr) {
+			return err
+		}
+	}
+
+	return errContention
+}
+
+// ifVersion runs the commands of fn in a transaction while the digest of the
+// stored field of the key is the version, an empty version matches a missing
+// key
+func (h *hash) ifVersion(ctx context.Context, key, version string, fn func(rd.Pipeliner)) error {
+	err := h.watch(ctx, key, func(raw string, ok bool) (func(rd.Pipeliner), error) {
+		if (ok && digest(raw) != version) || (!ok && version != "") {
+			return nil, cachego.ErrVersionMismatch
+		}
+
+		return fn, nil
+	})
+
+	if errors.Is(err, errContention) {
+		return cachego.ErrVersionMismatch
+	}
+
+	return err
+}
+
+// Contains checks if cached key exists in Redis hash storage
+func (h *hash) Contains(key string) bool {
+	return h.ContainsContext(context.Background(), key)
+}
+
+// ContainsContext checks if cached key exists in Redis hash storage
+func (h *hash) ContainsContext(ctx context.Context, key string) bool {
+	_, err := h.read(ctx, key)
+	return err == nil
+}
+
+// Delete the cached key from Redis hash storage
+func (h *hash) Delete(key string) error {
+	return h.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext the cached key from Redis hash storage
+func (h *hash) DeleteContext(ctx context.Context, key string) error {
+	return h.driver.HDel(ctx, h.key(), key).Err()
+}
+
+// DeleteMulti removes multiple cached keys from Redis hash storage at once
+func (h *hash) DeleteMulti(keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return h.driver.HDel(context.Background(), h.key(), keys...).Err()
+}
+
+// Fetch retrieves the cached value from key of the Redis hash storage
+func (h *hash) Fetch(key string) (string, error) {
+	return h.FetchContext(context.Background(), key)
+}
+
+// FetchContext retrieves the cached value from key of the Redis hash storage
+func (h *hash) FetchContext(ctx context.Context, key string) (string, error) {
+	value, err := h.read(ctx, key)
+	return string(value), err
+}
+
+// FetchBytes retrieves the cached binary value from key of the Redis hash storage
+func (h *hash) FetchBytes(key string) ([]byte, error) {
+	return h.read(context.Background(), key)
+}
+
+// FetchMulti retrieves multiple cached value from keys of the Redis hash storage
+func (h *hash) FetchMulti(keys []string) map[string]string {
+	return h.FetchMultiContext(context.Background(), keys)
+}
+
+// FetchMultiContext retrieves multiple cached value from keys of the Redis hash storage
+func (h *hash) FetchMultiContext(ctx context.Context, keys []string) map[string]string {
+	result := make(map[string]string)
+
+	if len(keys) == 0 {
+		return result
+	}
+
+	items, err := h.driver.HMGet(ctx, h.key(), keys...).Result()
+	if err != nil {
+		return result
+	}
+
+	now := time.Now().UnixNano()
+
+	for i, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			continue
+		}
+
+		value, expiresAt := openExpiry([]byte(s))
+
+		if expiresAt == 0 || expiresAt > now {
+			result[keys[i]] = string(value)
+		}
+	}
+
+	return result
+}
+
+// FetchMultiBytes retrieves multiple cached binary value from keys of the Redis hash storage
+func (h *hash) FetchMultiBytes(keys []string) map[string][]byte {
+	result := make(map[string][]byte)
+
+	for key, value := range h.FetchMulti(keys) {
+		result[key] = []byte(value)
+	}
+
+	return result
+}
+
+// Flush removes all cached keys of the Redis hash storage, by unlinking the hash
+func (h *hash) Flush() error {
+	return h.FlushContext(context.Background())
+}
+
+// FlushContext removes all cached keys of the Redis hash storage, by unlinking the hash
+func (h *hash) FlushContext(ctx context.Context) error {
+	return h.driver.Unlink(ctx, h.key()).Err()
+}
+
+// Namespace returns a view of the Redis hash storage keeping the keys in a
+// hash of its own, named after the prefix and a colon
+func (h *hash) Namespace(prefix string) cachego.Cache {
+	return &hash{driver: h.driver, prefix: h.prefix + prefix + ":", name: h.name, expire: h.expire}
+}
+
+// Save a value in Redis hash storage by key
+func (h *hash) Save(key string, value string, lifeTime time.Duration) error {
+	return h.SaveContext(context.Background(), key, value, lifeTime)
+}
+
+// SaveContext a value in Redis hash storage by key
+func (h *hash) SaveContext(ctx context.Context, key string, value string, lifeTime time.Duration) error {
+	return h.write(ctx, map[string][]byte{key: []byte(value)}, lifeTime)
+}
+
+// SaveBytes a binary value in Redis hash storage by key
+func (h *hash) SaveBytes(key string, value []byte, lifeTime time.Duration) error {
+	return h.write(context.Background(), map[string][]byte{key: value}, lifeTime)
+}
+
+// SaveMulti multiple values in Redis hash storage by their keys at once
+func (h *hash) SaveMulti(values map[string]string, lifeTime time.Duration) error {
+	fields := make(map[string][]byte, len(values))
+
+	for key, value := range values {
+		fields[key] = []byte(value)
+	}
+
+	return h.write(context.Background(), fields, lifeTime)
+}
+
+// FetchWithVersion retrieves the cached value from key of the Redis hash
+// storage along with its version, the digest of the stored field
+func (h *hash) FetchWithVersion(key string) (string, string, error) {
+	raw, value, err := h.field(context.Background(), h.driver, key)
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(value), digest(raw), nil
+}
+
+// SaveIfVersion a value in Redis hash storage by key, only when its version
+// is still the given one, inside a WATCH transaction of the hash
+func (h *hash) SaveIfVersion(key, value, version string, lifeTime time.Duration) error {
+	ctx := context.Background()
+
+	expire, err := h.mode(ctx, lifeTime)
+	if err != nil {
+		return err
+	}
+
+	return h.ifVersion(ctx, key, version, func(pipe rd.Pipeliner) {
+		h.set(ctx, pipe, map[string][]byte{key: []byte(value)}, lifeTime, expire)
+	})
+}
+
+// DeleteIfVersion removes the cached key from Redis hash storage, only when
+// its version is still the given one
+func (h *hash) DeleteIfVersion(key, version string) error {
+	if version == "" {
+		return cachego.ErrVersionMismatch
+	}
+
+	ctx := context.Background()
+
+	return h.ifVersion(ctx, key, version, func(pipe rd.Pipeliner) {
+		pipe.HDel(ctx, h.key(), key)
+	})
+}
+
+// SaveIfAbsent a value in Redis hash storage by key, only when the key is
+// missing or expired, inside a WATCH transaction of the hash
+func (h *hash) SaveIfAbsent(key string, value string, lifeTime time.Duration) (bool, error) {
+	return h.saveIf(key, value, lifeTime, false)
+}
+
+// Replace a value in Redis hash storage by key, only when the key exists,
+// inside a WATCH transaction of the hash
+func (h *hash) Replace(key string, value string, lifeTime time.Duration) (bool, error) {
+	return h.saveIf(key, value, lifeTime, true)
+}
+
+// saveIf saves the value when the existence of the key is the expected one
+func (h *hash) saveIf(key string, value string, lifeTime time.Duration, exists bool) (bool, error) {
+	ctx := context.Background()
+
+	expire, err := h.mode(ctx, lifeTime)
+	if err != nil {
+		return false, err
+	}
+
+	saved := false
+
+	err = h.watch(ctx, key, func(_ string, ok bool) (func(rd.Pipeliner), error) {
+		if saved = ok == exists; !saved {
+			return nil, nil
+		}
+
+		return func(pipe rd.Pipeliner) {
+			h.set(ctx, pipe, map[string][]byte{key: []byte(value)}, lifeTime, expire)
+		}, nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return saved, nil
+}
+
+// Touch updates the life time of the cached key in Redis hash storage, by
+// saving its value again inside a WATCH transaction of the hash
+func (h *hash) Touch(key string, lifeTime time.Duration) error {
+	ctx := context.Background()
+
+	expire, err := h.mode(ctx, lifeTime)
+	if err != nil {
+		return err
+	}
+
+	return h.watch(ctx, key, func(raw string, ok bool) (func(rd.Pipeliner), error) {
+		if !ok {
+			return nil, cachego.ErrCacheMiss
+		}
+
+		value, _ := openExpiry([]byte(raw))
+
+		return func(pipe rd.Pipeliner) {
+			h.set(ctx, pipe, map[string][]byte{key: value}, lifeTime, expire)
+
+			// the fields expiring by HPEXPIRE keep it when they are set again
+			if lifeTime <= 0 && h.expire.Load() == expireNative {
+				pipe.HPersist(ctx, h.key(), key)
+			}
+		}, nil
+	})
+}
+
+// Incr increments the value of the cached key in Redis hash storage by
+// HINCRBY, keeping its life time. The values in an envelope are incremented
+// inside a WATCH transaction of the hash.
+func (h *hash) Incr(key string, delta int64) (int64, error) {
+	ctx := context.Background()
+
+	value, err := h.driver.HIncrBy(ctx, h.key(), key, delta).Result()
+	if err = countErr(err); !errors.Is(err, cachego.ErrNotInteger) {
+		return value, err
+	}
+
+	err = h.watch(ctx, key, func(raw string, ok bool) (func(rd.Pipeliner), error) {
+		data, expiresAt := openExpiry([]byte(raw))
+
+		n, err := strconv.ParseInt(string(data), 10, 64)
+		if ok && err != nil {
+			return nil, cachego.ErrNotInteger
+		}
+
+		if value = delta; ok {
+			value += n
+		}
+
+		data = []byte(strconv.FormatInt(value, 10))
+
+		if ok && expiresAt > 0 {
+			data = wrapExpiry(data, uint64(expiresAt))
+		}
+
+		return func(pipe rd.Pipeliner) {
+			pipe.HSet(ctx, h.key(), key, data)
+		}, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return value, nil
+}
+
+// Decr decrements the value of the cached key in Redis hash storage, keeping
+// its life time
+func (h *hash) Decr(key string, delta int64) (int64, error) {
+	return h.Incr(key, -delta)
+}
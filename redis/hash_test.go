@@ -0,0 +1,224 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	rd "github.com/redis/go-redis/v9"
+
+	"github.com/faabiosr/cachego"
+	"github.com/faabiosr/cachego/cachegotest"
+)
+
+func TestRedisHash(t *testing.T) {
+	conn := rd.NewClient(&rd.Options{
+		Addr: ":6379",
+	})
+
+	if _, err := net.Dial("tcp", "localhost:6379"); err != nil {
+		t.Skip(err)
+	}
+
+	ctx := context.Background()
+	c := New(conn, WithPrefix("cachego:"), WithHash("hash"))
+
+	_ = conn.Set(ctx, "other", testValue, 0).Err()
+
+	t.Cleanup(func() {
+		_ = conn.Del(ctx, "other").Err()
+	})
+
+	if err := c.Save(testKey, testValue, 1*time.Second); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	_ = c.Save("bar", testValue, 0)
+
+	if n, _ := conn.HLen(ctx, "cachego:hash").Result(); n != 2 {
+		t.Errorf("save failed: expected %d fields, got %d", 2, n)
+	}
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	if values := c.FetchMulti([]string{testKey, "bar", "baz"}); len(values) != 2 || values[testKey] != testValue {
+		t.Errorf("fetch multi failed: expected %d, got %v", 2, values)
+	}
+
+	time.Sleep(2 * time.Second)
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+
+	if values := c.FetchMulti([]string{testKey, "bar"}); len(values) != 1 {
+		t.Errorf("fetch multi failed: expected %d, got %v", 1, values)
+	}
+
+	if err := c.Flush(); err != nil {
+		t.Errorf("flush failed: expected nil, got %v", err)
+	}
+
+	if n, _ := conn.Exists(ctx, "cachego:hash").Result(); n != 0 {
+		t.Errorf("flush failed: expected the hash to be unlinked, got %d", n)
+	}
+
+	if res, _ := conn.Get(ctx, "other").Result(); res != testValue {
+		t.Errorf("flush failed: expected the key out of the hash %s, got %s", testValue, res)
+	}
+}
+
+func TestRedisHashEnvelope(t *testing.T) {
+	conn := rd.NewClient(&rd.Options{
+		Addr: ":6379",
+	})
+
+	if _, err := net.Dial("tcp", "localhost:6379"); err != nil {
+		t.Skip(err)
+	}
+
+	c := New(conn, WithHash("cachego:envelope")).(*hash)
+	c.expire.Store(expireEnvelope)
+
+	t.Cleanup(func() {
+		_ = c.Flush()
+	})
+
+	_ = c.Save(testKey, testValue, time.Hour)
+
+	value, _ := conn.HGet(context.Background(), "cachego:envelope", testKey).Bytes()
+
+	if res, expiresAt := openExpiry(value); string(res) != testValue || expiresAt <= time.Now().UnixNano() {
+		t.Errorf("save failed: expected %s expiring later, got %s expiring at %d", testValue, res, expiresAt)
+	}
+
+	_ = c.Save(testKey, testValue, 1*time.Nanosecond)
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheExpired) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheExpired, err)
+	}
+
+	if n, _ := conn.HExists(context.Background(), "cachego:envelope", testKey).Result(); n {
+		t.Errorf("fetch failed: the expired field %s should be deleted", testKey)
+	}
+}
+
+func TestRedisHashCAS(t *testing.T) {
+	conn := rd.NewClient(&rd.Options{
+		Addr: ":6379",
+	})
+
+	if _, err := net.Dial("tcp", "localhost:6379"); err != nil {
+		t.Skip(err)
+	}
+
+	c := New(conn, WithHash("cachego:cas")).(*hash)
+
+	t.Cleanup(func() {
+		_ = c.Flush()
+	})
+
+	// the support of HPEXPIRE is probed first, then the envelope is forced
+	for _, expire := range []int32{expireUnknown, expireEnvelope} {
+		c.expire.Store(expire)
+
+		_ = c.Save(testKey, testValue, time.Hour)
+		_, version, _ := c.FetchWithVersion(testKey)
+
+		// the other fields of the hash do not change the version of the key
+		_ = c.Save("bar", testValue, 0)
+
+		if err := c.SaveIfVersion(testKey, "baz", version, time.Hour); err != nil {
+			t.Errorf("save fail: expected nil, got %v", err)
+		}
+
+		if err := c.SaveIfVersion(testKey, "qux", version, time.Hour); !errors.Is(err, cachego.ErrVersionMismatch) {
+			t.Errorf("save fail: expected %v, got %v", cachego.ErrVersionMismatch, err)
+		}
+
+		if res, _ := c.Fetch(testKey); res != "baz" {
+			t.Errorf("fetch fail, wrong value: expected %s, got %s", "baz", res)
+		}
+
+		_ = c.Save(testKey, testValue, 1*time.Nanosecond)
+
+		if err := c.SaveIfVersion(testKey, testValue, "", 0); err != nil {
+			t.Errorf("save fail: expected nil for an expired key, got %v", err)
+		}
+	}
+}
+
+func TestRedisHashAtomicEnvelope(t *testing.T) {
+	conn := rd.NewClient(&rd.Options{
+		Addr: ":6379",
+	})
+
+	if _, err := net.Dial("tcp", "localhost:6379"); err != nil {
+		t.Skip(err)
+	}
+
+	c := New(conn, WithHash("cachego:atomic")).(*hash)
+	c.expire.Store(expireEnvelope)
+
+	t.Cleanup(func() {
+		_ = c.Flush()
+	})
+
+	if ok, err := c.SaveIfAbsent(testKey, "5", time.Hour); !ok || err != nil {
+		t.Errorf("save fail: expected true, got %v (%v)", ok, err)
+	}
+
+	if n, err := c.Incr(testKey, 2); n != 7 || err != nil {
+		t.Errorf("incr failed: expected %d, got %d (%v)", 7, n, err)
+	}
+
+	value, _ := conn.HGet(context.Background(), "cachego:atomic", testKey).Bytes()
+
+	if res, expiresAt := openExpiry(value); string(res) != "7" || expiresAt <= time.Now().UnixNano() {
+		t.Errorf("incr failed: expected %s keeping its expiration, got %s expiring at %d", "7", res, expiresAt)
+	}
+
+	if err := c.Touch(testKey, 1*time.Nanosecond); err != nil {
+		t.Errorf("touch failed: expected nil, got %v", err)
+	}
+
+	if ok, _ := c.Replace(testKey, testValue, 0); ok {
+		t.Errorf("replace failed: the expired key %s should not be replaced", testKey)
+	}
+
+	if n, err := c.Decr(testKey, 3); n != -3 || err != nil {
+		t.Errorf("decr failed: expected %d for an expired key, got %d (%v)", -3, n, err)
+	}
+
+	_ = c.Save(testKey, testValue, time.Hour)
+
+	if _, err := c.Incr(testKey, 1); !errors.Is(err, cachego.ErrNotInteger) {
+		t.Errorf("incr failed: expected %v, got %v", cachego.ErrNotInteger, err)
+	}
+}
+
+func TestRedisHashSuite(t *testing.T) {
+	if _, err := net.Dial("tcp", "localhost:6379"); err != nil {
+		t.Skip(err)
+	}
+
+	cachegotest.RunSuite(t, func(t *testing.T) cachego.Cache {
+		c := New(rd.NewClient(&rd.Options{Addr: ":6379"}), WithHash("cachego:suite"))
+
+		t.Cleanup(func() {
+			_ = c.Flush()
+		})
+
+		return c
+	})
+}
+
+func TestOpenExpiry(t *testing.T) {
+	if res, expiresAt := openExpiry([]byte(testValue)); string(res) != testValue || expiresAt != 0 {
+		t.Errorf("open expiry failed: expected %s without expiration, got %s (%d)", testValue, res, expiresAt)
+	}
+}
@@ -3,6 +3,11 @@ package redis
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	rd "github.com/redis/go-redis/v9"
@@ -10,55 +15,536 @@ import (
 	"github.com/faabiosr/cachego"
 )
 
-type redis struct {
-	driver rd.Cmdable
-}
+type (
+	redis struct {
+		driver  rd.Cmdable
+		prefix  string
+		hash    string
+		flushDB bool
+		cluster bool
+		local   cachego.Cache
+	}
+
+	// Option configures the Redis cache driver
+	Option func(*redis)
+
+	// watcher is implemented by the clients running optimistic transactions
+	watcher interface {
+		Watch(ctx context.Context, fn func(*rd.Tx) error, keys ...string) error
+	}
+)
+
+var errWatch = errors.New("redis: the driver does not support WATCH")
+
+var (
+	// compareAndDelete deletes the key while it holds the value
+	compareAndDelete = rd.NewScript(`
+		if redis.call("GET", KEYS[1]) == ARGV[1] then
+			return redis.call("DEL", KEYS[1])
+		end
+		return 0
+	`)
+
+	// compareAndExpire sets the life time in milliseconds of the key while it
+	// holds the value
+	compareAndExpire = rd.NewScript(`
+		if redis.call("GET", KEYS[1]) == ARGV[1] then
+			return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+		end
+		return 0
+	`)
+)
+
+// scanCount is the number of keys requested by each SCAN of the Flush, and
+// removed by each batch of UNLINK
+const scanCount = 100
 
 // New creates an instance of Redis cache driver
-func New(driver rd.Cmdable) cachego.Cache {
-	return &redis{driver}
+func New(driver rd.Cmdable, opts ...Option) cachego.Cache {
+	r := &redis{driver: driver}
+	_, r.cluster = driver.(*rd.ClusterClient)
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.hash != "" {
+		return &hash{driver: driver, prefix: r.prefix, name: r.hash, expire: &atomic.Int32{}}
+	}
+
+	if client, ok := driver.(*rd.Client); ok && r.local != nil {
+		return newTracking(r, client)
+	}
+
+	return r
+}
+
+// WithPrefix sets the prefix of all the keys, the Flush only removes the
+// prefixed keys
+func WithPrefix(prefix string) Option {
+	return func(r *redis) {
+		r.prefix = prefix
+	}
+}
+
+// WithHash stores the cached keys as the fields of the hash name, prefixed
+// by WithPrefix, so that the Flush only unlinks the hash. The life time of
+// the keys is set by HPEXPIRE, on the servers without it the expiration is
+// kept along with the value.
+func WithHash(name string) Option {
+	return func(r *redis) {
+		r.hash = name
+	}
+}
+
+// WithTracking keeps the values read in the local cache, e.g. a bounded
+// memory driver, invalidated by the server through the RESP3 client-side
+// caching in BCAST mode of the keys prefixed by WithPrefix. The keys are
+// tracked by a connection of their own until the cache is closed, the local
+// copies are not used while it is down. It requires Redis 6 or later, it is
+// ignored on clusters and by the hash mode.
+func WithTracking(local cachego.Cache) Option {
+	return func(r *redis) {
+		r.local = local
+	}
+}
+
+// WithFlushDB makes the Flush without prefix call FLUSHDB, removing every key
+// of the database instead of scanning them
+func WithFlushDB() Option {
+	return func(r *redis) {
+		r.flushDB = true
+	}
 }
 
 // Contains checks if cached key exists in Redis storage
 func (r *redis) Contains(key string) bool {
-	i, _ := r.driver.Exists(context.Background(), key).Result()
+	return r.ContainsContext(context.Background(), key)
+}
+
+// ContainsContext checks if cached key exists in Redis storage
+func (r *redis) ContainsContext(ctx context.Context, key string) bool {
+	i, _ := r.driver.Exists(ctx, r.prefix+key).Result()
 	return i > 0
 }
 
 // Delete the cached key from Redis storage
 func (r *redis) Delete(key string) error {
-	return r.driver.Del(context.Background(), key).Err()
+	return r.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext the cached key from Redis storage
+func (r *redis) DeleteContext(ctx context.Context, key string) error {
+	return r.driver.Del(ctx, r.prefix+key).Err()
+}
+
+// DeleteMulti removes multiple cached keys from Redis storage, on a cluster
+// the keys are deleted by hash slot in a pipeline
+func (r *redis) DeleteMulti(keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	prefixed := r.keys(keys)
+
+	_, err := r.driver.Pipelined(ctx, func(pipe rd.Pipeliner) error {
+		for _, group := range r.groups(prefixed) {
+			pipe.Del(ctx, group...)
+		}
+
+		return nil
+	})
+
+	return err
 }
 
 // Fetch retrieves the cached value from key of the Redis storage
 func (r *redis) Fetch(key string) (string, error) {
-	return r.driver.Get(context.Background(), key).Result()
+	return r.FetchContext(context.Background(), key)
+}
+
+// FetchContext retrieves the cached value from key of the Redis storage
+func (r *redis) FetchContext(ctx context.Context, key string) (string, error) {
+	value, err := r.driver.Get(ctx, r.prefix+key).Result()
+	return value, cacheErr(err)
+}
+
+// FetchBytes retrieves the cached binary value from key of the Redis storage
+func (r *redis) FetchBytes(key string) ([]byte, error) {
+	value, err := r.driver.Get(context.Background(), r.prefix+key).Bytes()
+	return value, cacheErr(err)
+}
+
+// cacheErr converts the redis nil reply into a cache miss
+func cacheErr(err error) error {
+	if errors.Is(err, rd.Nil) {
+		return cachego.ErrCacheMiss
+	}
+
+	return err
+}
+
+// FetchWithVersion retrieves the cached value from key of the Redis storage
+// and the digest of the value as version
+func (r *redis) FetchWithVersion(key string) (string, string, error) {
+	value, err := r.Fetch(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	return value, digest(value), nil
+}
+
+// digest returns the SHA-256 of the value in hex
+func digest(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
 }
 
 // FetchMulti retrieves multiple cached value from keys of the Redis storage
 func (r *redis) FetchMulti(keys []string) map[string]string {
+	return r.FetchMultiContext(context.Background(), keys)
+}
+
+// FetchMultiContext retrieves multiple cached value from keys of the Redis
+// storage, on a cluster the keys are fetched by hash slot in a pipeline
+// fanning out to their masters
+func (r *redis) FetchMultiContext(ctx context.Context, keys []string) map[string]string {
 	result := make(map[string]string)
 
-	items, err := r.driver.MGet(context.Background(), keys...).Result()
-	if err != nil {
+	if len(keys) == 0 {
 		return result
 	}
 
-	for i := 0; i < len(keys); i++ {
-		if items[i] != nil {
-			result[keys[i]] = items[i].(string)
+	prefixed := r.keys(keys)
+	groups := r.groups(prefixed)
+	cmds := make([]*rd.SliceCmd, len(groups))
+
+	// the failed groups are left out, their error is kept by each command
+	_, _ = r.driver.Pipelined(ctx, func(pipe rd.Pipeliner) error {
+		for i, group := range groups {
+			cmds[i] = pipe.MGet(ctx, group...)
+		}
+
+		return nil
+	})
+
+	for i, cmd := range cmds {
+		items, err := cmd.Result()
+		if err != nil {
+			continue
+		}
+
+		for j, item := range items {
+			if value, ok := item.(string); ok {
+				result[strings.TrimPrefix(groups[i][j], r.prefix)] = value
+			}
 		}
 	}
 
 	return result
 }
 
+// keys prefixes the keys
+func (r *redis) keys(keys []string) []string {
+	prefixed := make([]string, len(keys))
+
+	for i, key := range keys {
+		prefixed[i] = r.prefix + key
+	}
+
+	return prefixed
+}
+
+// groups splits the keys by hash slot on a cluster, a single group holds all
+// the keys otherwise
+func (r *redis) groups(keys []string) [][]string {
+	if !r.cluster {
+		return [][]string{keys}
+	}
+
+	return groupBySlot(keys)
+}
+
+// FetchMultiBytes retrieves multiple cached binary value from keys of the Redis storage
+func (r *redis) FetchMultiBytes(keys []string) map[string][]byte {
+	result := make(map[string][]byte)
+
+	for key, value := range r.FetchMulti(keys) {
+		result[key] = []byte(value)
+	}
+
+	return result
+}
+
 // Flush removes all cached keys of the Redis storage
 func (r *redis) Flush() error {
-	return r.driver.FlushAll(context.Background()).Err()
+	return r.FlushContext(context.Background())
+}
+
+// FlushContext removes all cached keys of the Redis storage, the keys are
+// scanned by the prefix and unlinked in batches on each master of a cluster
+func (r *redis) FlushContext(ctx context.Context) error {
+	if r.prefix == "" && r.flushDB {
+		return r.forEachMaster(ctx, func(ctx context.Context, client rd.Cmdable) error {
+			return client.FlushDB(ctx).Err()
+		})
+	}
+
+	return r.forEachMaster(ctx, func(ctx context.Context, client rd.Cmdable) error {
+		return unlink(ctx, client, escapePattern(r.prefix)+"*")
+	})
+}
+
+// forEachMaster calls fn with each master of a cluster, or the driver itself
+func (r *redis) forEachMaster(ctx context.Context, fn func(context.Context, rd.Cmdable) error) error {
+	cluster, ok := r.driver.(*rd.ClusterClient)
+	if !ok {
+		return fn(ctx, r.driver)
+	}
+
+	return cluster.ForEachMaster(ctx, func(ctx context.Context, client *rd.Client) error {
+		return fn(ctx, client)
+	})
+}
+
+// unlink removes the keys matching the pattern, each key is unlinked on its
+// own so that the keys of a batch may belong to different cluster slots
+func unlink(ctx context.Context, client rd.Cmdable, pattern string) error {
+	iter := client.Scan(ctx, 0, pattern, scanCount).Iterator()
+	keys := make([]string, 0, scanCount)
+
+	for iter.Next(ctx) {
+		if keys = append(keys, iter.Val()); len(keys) < scanCount {
+			continue
+		}
+
+		if err := unlinkBatch(ctx, client, keys); err != nil {
+			return err
+		}
+
+		keys = keys[:0]
+	}
+
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	return unlinkBatch(ctx, client, keys)
+}
+
+func unlinkBatch(ctx context.Context, client rd.Cmdable, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_, err := client.Pipelined(ctx, func(pipe rd.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Unlink(ctx, key)
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+// escapePattern escapes the glob characters of the SCAN pattern
+func escapePattern(s string) string {
+	var b strings.Builder
+
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+
+		b.WriteRune(c)
+	}
+
+	return b.String()
+}
+
+// Namespace returns a view of the Redis storage that prefixes all the keys
+// by the prefix and a colon, its Flush only removes the prefixed keys
+func (r *redis) Namespace(prefix string) cachego.Cache {
+	return &redis{driver: r.driver, prefix: r.prefix + prefix + ":", flushDB: r.flushDB, cluster: r.cluster}
 }
 
 // Save a value in Redis storage by key
 func (r *redis) Save(key string, value string, lifeTime time.Duration) error {
-	return r.driver.Set(context.Background(), key, value, lifeTime).Err()
+	return r.SaveContext(context.Background(), key, value, lifeTime)
+}
+
+// SaveContext a value in Redis storage by key
+func (r *redis) SaveContext(ctx context.Context, key string, value string, lifeTime time.Duration) error {
+	return r.driver.Set(ctx, r.prefix+key, value, lifeTime).Err()
+}
+
+// SaveBytes a binary value in Redis storage by key
+func (r *redis) SaveBytes(key string, value []byte, lifeTime time.Duration) error {
+	return r.driver.Set(context.Background(), r.prefix+key, value, lifeTime).Err()
+}
+
+// SaveMulti multiple values in Redis storage by their keys in a pipeline, on a
+// cluster each key is sent to its own master
+func (r *redis) SaveMulti(values map[string]string, lifeTime time.Duration) error {
+	ctx := context.Background()
+
+	_, err := r.driver.Pipelined(ctx, func(pipe rd.Pipeliner) error {
+		for key, value := range values {
+			pipe.Set(ctx, r.prefix+key, value, lifeTime)
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+// SaveIfVersion a value in Redis storage by key while the digest of its value
+// is the version, the key is watched so that a concurrent write makes the
+// transaction fail
+func (r *redis) SaveIfVersion(key, value, version string, lifeTime time.Duration) error {
+	return r.ifVersion(key, version, func(ctx context.Context, pipe rd.Pipeliner) {
+		pipe.Set(ctx, r.prefix+key, value, lifeTime)
+	})
+}
+
+// DeleteIfVersion removes the cached key from Redis storage while the digest
+// of its value is the version, as SaveIfVersion does
+func (r *redis) DeleteIfVersion(key, version string) error {
+	if version == "" {
+		return cachego.ErrVersionMismatch
+	}
+
+	return r.ifVersion(key, version, func(ctx context.Context, pipe rd.Pipeliner) {
+		pipe.Del(ctx, r.prefix+key)
+	})
+}
+
+// ifVersion runs the commands of fn in a transaction while the digest of the
+// value of the key is the version, an empty version matches a missing key
+func (r *redis) ifVersion(key, version string, fn func(context.Context, rd.Pipeliner)) error {
+	w, ok := r.driver.(watcher)
+	if !ok {
+		return errWatch
+	}
+
+	ctx := context.Background()
+	key = r.prefix + key
+
+	err := w.Watch(ctx, func(tx *rd.Tx) error {
+		current, err := tx.Get(ctx, key).Result()
+
+		switch {
+		case errors.Is(err, rd.Nil):
+			if version != "" {
+				return cachego.ErrVersionMismatch
+			}
+		case err != nil:
+			return err
+		case digest(current) != version:
+			return cachego.ErrVersionMismatch
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe rd.Pipeliner) error {
+			fn(ctx, pipe)
+			return nil
+		})
+
+		return err
+	}, key)
+
+	if errors.Is(err, rd.TxFailedErr) {
+		return cachego.ErrVersionMismatch
+	}
+
+	return err
+}
+
+// SaveIfAbsent a value in Redis storage by key by SET NX, only when the key
+// is missing
+func (r *redis) SaveIfAbsent(key string, value string, lifeTime time.Duration) (bool, error) {
+	return r.driver.SetNX(context.Background(), r.prefix+key, value, lifeTime).Result()
+}
+
+// Replace a value in Redis storage by key by SET XX, only when the key exists
+func (r *redis) Replace(key string, value string, lifeTime time.Duration) (bool, error) {
+	return r.driver.SetXX(context.Background(), r.prefix+key, value, lifeTime).Result()
+}
+
+// Touch updates the life time of the cached key in Redis storage by PEXPIRE,
+// or PERSIST for a zero life time
+func (r *redis) Touch(key string, lifeTime time.Duration) error {
+	ctx := context.Background()
+	key = r.prefix + key
+
+	if lifeTime > 0 {
+		ok, err := r.driver.PExpire(ctx, key, lifeTime).Result()
+		if err == nil && !ok {
+			return cachego.ErrCacheMiss
+		}
+
+		return err
+	}
+
+	// PERSIST fails on the keys without life time as well as the missing ones
+	var exists *rd.IntCmd
+
+	_, err := r.driver.TxPipelined(ctx, func(pipe rd.Pipeliner) error {
+		exists = pipe.Exists(ctx, key)
+		pipe.Persist(ctx, key)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if exists.Val() == 0 {
+		return cachego.ErrCacheMiss
+	}
+
+	return nil
+}
+
+// Incr increments the value of the cached key in Redis storage by INCRBY,
+// keeping its life time
+func (r *redis) Incr(key string, delta int64) (int64, error) {
+	value, err := r.driver.IncrBy(context.Background(), r.prefix+key, delta).Result()
+	return value, countErr(err)
+}
+
+// Decr decrements the value of the cached key in Redis storage by DECRBY,
+// keeping its life time
+func (r *redis) Decr(key string, delta int64) (int64, error) {
+	value, err := r.driver.DecrBy(context.Background(), r.prefix+key, delta).Result()
+	return value, countErr(err)
+}
+
+// CompareAndDelete removes the cached key from Redis storage only while it
+// holds the value, checked and deleted at once by a script
+func (r *redis) CompareAndDelete(ctx context.Context, key, value string) (bool, error) {
+	n, err := compareAndDelete.Run(ctx, r.driver, []string{r.prefix + key}, value).Int()
+	return n == 1, err
+}
+
+// CompareAndExpire updates the life time of the cached key in Redis storage
+// only while it holds the value, checked and expired at once by a script
+func (r *redis) CompareAndExpire(ctx context.Context, key, value string, lifeTime time.Duration) (bool, error) {
+	ms := max(lifeTime.Milliseconds(), 1)
+
+	n, err := compareAndExpire.Run(ctx, r.driver, []string{r.prefix + key}, value, ms).Int()
+	return n == 1, err
+}
+
+// countErr converts the server error of the values that are not integers
+func countErr(err error) error {
+	if err != nil && strings.Contains(err.Error(), "not an integer") {
+		return cachego.ErrNotInteger
+	}
+
+	return err
 }
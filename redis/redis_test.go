@@ -1,11 +1,18 @@
 package redis
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"net"
 	"testing"
 	"time"
 
 	rd "github.com/redis/go-redis/v9"
+
+	"github.com/faabiosr/cachego"
+	"github.com/faabiosr/cachego/cachegotest"
 )
 
 const (
@@ -70,3 +77,300 @@ func TestRedis(t *testing.T) {
 		t.Errorf("fetch multi failed: expected %d, got %d", 0, len(values))
 	}
 }
+
+func TestRedisContext(t *testing.T) {
+	if _, err := net.Dial("tcp", "localhost:6379"); err != nil {
+		t.Skip(err)
+	}
+
+	c := New(rd.NewClient(&rd.Options{Addr: ":6379"})).(cachego.ContextCache)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if err := c.SaveContext(ctx, testKey, testValue, 0); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.FetchContext(ctx, testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	cancel()
+
+	if err := c.SaveContext(ctx, testKey, testValue, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("save failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if _, err := c.FetchContext(ctx, testKey); !errors.Is(err, context.Canceled) {
+		t.Errorf("fetch failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if err := c.DeleteContext(ctx, testKey); !errors.Is(err, context.Canceled) {
+		t.Errorf("delete failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if err := c.FlushContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("flush failed: expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestRedisBytes(t *testing.T) {
+	if _, err := net.Dial("tcp", "localhost:6379"); err != nil {
+		t.Skip(err)
+	}
+
+	c := New(rd.NewClient(&rd.Options{Addr: ":6379"})).(cachego.BytesCache)
+	value := []byte{0x00, 0xff, 0xfe, '"', '\\'}
+
+	if err := c.SaveBytes(testKey, value, 10*time.Second); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.FetchBytes(testKey); !bytes.Equal(res, value) {
+		t.Errorf("fetch fail, wrong value: expected %v, got %v", value, res)
+	}
+
+	if values := c.FetchMultiBytes([]string{testKey, "baz"}); !bytes.Equal(values[testKey], value) {
+		t.Errorf("fetch multi failed, wrong value: expected %v, got %v", value, values[testKey])
+	}
+}
+
+func TestRedisCacheMiss(t *testing.T) {
+	if _, err := net.Dial("tcp", "localhost:6379"); err != nil {
+		t.Skip(err)
+	}
+
+	c := New(rd.NewClient(&rd.Options{Addr: ":6379"}))
+	_ = c.Delete(testKey)
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+
+	if _, err := c.(cachego.BytesCache).FetchBytes(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+}
+
+func TestRedisStale(t *testing.T) {
+	if _, err := net.Dial("tcp", "localhost:6379"); err != nil {
+		t.Skip(err)
+	}
+
+	c := cachego.NewStaleCache(New(rd.NewClient(&rd.Options{Addr: ":6379"})))
+
+	if err := c.SaveStale(testKey, testValue, 1*time.Nanosecond, 10*time.Second); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, stale, _ := c.FetchStale(testKey); res != testValue || !stale {
+		t.Errorf("fetch stale fail: expected a stale %s, got %s (stale %v)", testValue, res, stale)
+	}
+
+	if values := c.FetchMulti([]string{testKey}); values[testKey] != testValue {
+		t.Errorf("fetch multi failed, wrong value: expected %s, got %s", testValue, values[testKey])
+	}
+
+	_ = c.SaveStale(testKey, testValue, 10*time.Second, 0)
+
+	if _, stale, _ := c.FetchStale(testKey); stale {
+		t.Errorf("fetch stale fail: the key %s should not be stale", testKey)
+	}
+}
+
+func TestRedisSuite(t *testing.T) {
+	if _, err := net.Dial("tcp", "localhost:6379"); err != nil {
+		t.Skip(err)
+	}
+
+	cachegotest.RunSuite(t, func(*testing.T) cachego.Cache {
+		return New(rd.NewClient(&rd.Options{Addr: ":6379"}))
+	})
+}
+
+func TestRedisNamespace(t *testing.T) {
+	conn := rd.NewClient(&rd.Options{
+		Addr: ":6379",
+	})
+
+	if _, err := net.Dial("tcp", "localhost:6379"); err != nil {
+		t.Skip(err)
+	}
+
+	c := New(conn)
+	ns := c.(cachego.Namespacer).Namespace("ns*[")
+
+	_ = c.Save("nsx[foo", testValue, 0)
+	_ = ns.Save(testKey, testValue, 0)
+
+	if res, _ := c.Fetch("ns*[:" + testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	if err := ns.Flush(); err != nil {
+		t.Errorf("flush failed: expected nil, got %v", err)
+	}
+
+	if ns.Contains(testKey) {
+		t.Errorf("contains failed: the key %s should not be exist", testKey)
+	}
+
+	if !c.Contains("nsx[foo") {
+		t.Errorf("flush failed: the key %s should be exist", "nsx[foo")
+	}
+
+	_ = c.Delete("nsx[foo")
+}
+
+func TestEscapePattern(t *testing.T) {
+	if res := escapePattern(`a*b?c[d]e\f`); res != `a\*b\?c\[d\]e\\f` {
+		t.Errorf("escape pattern failed: expected %s, got %s", `a\*b\?c\[d\]e\\f`, res)
+	}
+}
+
+func TestRedisPrefix(t *testing.T) {
+	conn := rd.NewClient(&rd.Options{
+		Addr: ":6379",
+	})
+
+	if _, err := net.Dial("tcp", "localhost:6379"); err != nil {
+		t.Skip(err)
+	}
+
+	c := New(conn, WithPrefix("cachego:"))
+
+	_ = conn.Set(context.Background(), "other", testValue, 0).Err()
+
+	for i := 0; i < 2*scanCount+1; i++ {
+		_ = c.Save(fmt.Sprint(i), testValue, 0)
+	}
+
+	if res, _ := conn.Get(context.Background(), "cachego:0").Result(); res != testValue {
+		t.Errorf("save failed: expected the prefixed key %s, got %s", testValue, res)
+	}
+
+	if values := c.FetchMulti([]string{"0", "1", "other"}); len(values) != 2 || values["1"] != testValue {
+		t.Errorf("fetch multi failed: expected %d, got %v", 2, values)
+	}
+
+	if err := c.Flush(); err != nil {
+		t.Errorf("flush failed: expected nil, got %v", err)
+	}
+
+	if n, _ := conn.Exists(context.Background(), "cachego:0", "cachego:200").Result(); n != 0 {
+		t.Errorf("flush failed: expected %d, got %d", 0, n)
+	}
+
+	if res, _ := conn.Get(context.Background(), "other").Result(); res != testValue {
+		t.Errorf("flush failed: expected the key out of the prefix %s, got %s", testValue, res)
+	}
+
+	_ = conn.Del(context.Background(), "other").Err()
+}
+
+func TestRedisFlushDB(t *testing.T) {
+	conn := rd.NewClient(&rd.Options{
+		Addr: ":6379",
+		DB:   1,
+	})
+
+	if _, err := net.Dial("tcp", "localhost:6379"); err != nil {
+		t.Skip(err)
+	}
+
+	other := rd.NewClient(&rd.Options{Addr: ":6379"})
+	_ = other.Set(context.Background(), "other", testValue, 0).Err()
+
+	t.Cleanup(func() {
+		_ = other.Del(context.Background(), "other").Err()
+	})
+
+	c := New(conn, WithFlushDB())
+	_ = c.Save(testKey, testValue, 0)
+
+	if err := c.Flush(); err != nil {
+		t.Errorf("flush failed: expected nil, got %v", err)
+	}
+
+	if c.Contains(testKey) {
+		t.Errorf("contains failed: the key %s should not be exist", testKey)
+	}
+
+	if res, _ := other.Get(context.Background(), "other").Result(); res != testValue {
+		t.Errorf("flush failed: expected the key of another database %s, got %s", testValue, res)
+	}
+}
+
+func TestRedisCluster(t *testing.T) {
+	if _, err := net.Dial("tcp", "localhost:6379"); err != nil {
+		t.Skip(err)
+	}
+
+	conn := rd.NewClusterClient(&rd.ClusterOptions{
+		Addrs: []string{":6379"},
+	})
+
+	if err := conn.ClusterSlots(context.Background()).Err(); err != nil {
+		t.Skip(err)
+	}
+
+	c := New(conn, WithPrefix("cachego:"))
+
+	_ = c.Save(testKey, testValue, 0)
+	_ = c.Save("bar", testValue, 0)
+
+	if err := c.Flush(); err != nil {
+		t.Errorf("flush failed: expected nil, got %v", err)
+	}
+
+	if c.Contains(testKey) || c.Contains("bar") {
+		t.Errorf("contains failed: the keys %s and %s should not be exist", testKey, "bar")
+	}
+}
+
+func TestRedisSaveIfVersionWatch(t *testing.T) {
+	conn := rd.NewClient(&rd.Options{Addr: runMiniredis(t).Addr()})
+	t.Cleanup(func() { _ = conn.Close() })
+
+	// the embedding hides the Watch of the client
+	c := New(struct{ rd.Cmdable }{conn}).(cachego.CASCache)
+
+	if err := c.SaveIfVersion(testKey, testValue, "", 0); !errors.Is(err, errWatch) {
+		t.Errorf("save failed: expected %v, got %v", errWatch, err)
+	}
+}
+
+func TestRedisCompareAndDelete(t *testing.T) {
+	m := runMiniredis(t)
+	conn := rd.NewClient(&rd.Options{Addr: m.Addr()})
+	t.Cleanup(func() { _ = conn.Close() })
+
+	c := New(conn, WithPrefix("cachego:")).(*redis)
+	ctx := context.Background()
+
+	_ = c.Save(testKey, testValue, 0)
+
+	if ok, err := c.CompareAndExpire(ctx, testKey, "baz", time.Minute); ok || err != nil {
+		t.Errorf("compare and expire failed: expected false for another value, got %v (%v)", ok, err)
+	}
+
+	if ok, err := c.CompareAndExpire(ctx, testKey, testValue, time.Minute); !ok || err != nil {
+		t.Errorf("compare and expire failed: expected true, got %v (%v)", ok, err)
+	}
+
+	if ttl := m.TTL("cachego:" + testKey); ttl != time.Minute {
+		t.Errorf("compare and expire failed, wrong life time: expected %v, got %v", time.Minute, ttl)
+	}
+
+	if ok, err := c.CompareAndDelete(ctx, testKey, "baz"); ok || err != nil {
+		t.Errorf("compare and delete failed: expected false for another value, got %v (%v)", ok, err)
+	}
+
+	if ok, err := c.CompareAndDelete(ctx, testKey, testValue); !ok || err != nil {
+		t.Errorf("compare and delete failed: expected true, got %v (%v)", ok, err)
+	}
+
+	if ok, err := c.CompareAndDelete(ctx, testKey, testValue); ok || err != nil {
+		t.Errorf("compare and delete failed: expected false for a missing key, got %v (%v)", ok, err)
+	}
+}
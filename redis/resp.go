@@ -0,0 +1,127 @@
+package redis
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type (
+	// push is a RESP3 out-of-band message, as the invalidations of the
+	// tracked keys
+	push []any
+
+	// replyError is an error replied by the server
+	replyError string
+)
+
+var errProtocol = errors.New("redis: invalid RESP3 reply")
+
+// Error returns the message replied by the server
+func (e replyError) Error() string {
+	return string(e)
+}
+
+// writeCommand writes the command as a RESP array of bulk strings
+func writeCommand(w io.Writer, args ...string) error {
+	var b strings.Builder
+
+	b.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+
+	for _, arg := range args {
+		b.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// readReply reads a RESP3 value, the aggregates are returned as []any with
+// the maps flattened, the pushes as push, the errors as replyError and the
+// other values as string, the nulls as nil
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errProtocol
+	}
+
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+', ':', ',', '(', '#':
+		return body, nil
+	case '-':
+		return replyError(body), nil
+	case '_':
+		return nil, nil
+	case '$', '=', '!':
+		return readBlob(r, kind, body)
+	case '*', '~', '>':
+		return readAggregate(r, kind, body, 1)
+	case '%':
+		return readAggregate(r, kind, body, 2)
+	case '|':
+		// the attributes are metadata of the reply that follows them
+		if _, err := readAggregate(r, kind, body, 2); err != nil {
+			return nil, err
+		}
+
+		return readReply(r)
+	}
+
+	return nil, errProtocol
+}
+
+func readBlob(r *bufio.Reader, kind byte, body string) (any, error) {
+	n, err := strconv.Atoi(body)
+	if err != nil {
+		return nil, errProtocol
+	}
+
+	if n < 0 {
+		return nil, nil
+	}
+
+	data := make([]byte, n+2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+
+	if kind == '!' {
+		return replyError(data[:n]), nil
+	}
+
+	return string(data[:n]), nil
+}
+
+func readAggregate(r *bufio.Reader, kind byte, body string, width int) (any, error) {
+	n, err := strconv.Atoi(body)
+	if err != nil {
+		return nil, errProtocol
+	}
+
+	if n < 0 {
+		return nil, nil
+	}
+
+	items := make([]any, n*width)
+
+	for i := range items {
+		if items[i], err = readReply(r); err != nil {
+			return nil, err
+		}
+	}
+
+	if kind == '>' {
+		return push(items), nil
+	}
+
+	return items, nil
+}
@@ -0,0 +1,82 @@
+package redis
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteCommand(t *testing.T) {
+	var b bytes.Buffer
+
+	if err := writeCommand(&b, "CLIENT", "TRACKING", "ON", ""); err != nil {
+		t.Errorf("write failed: expected nil, got %v", err)
+	}
+
+	expected := "*4\r\n$6\r\nCLIENT\r\n$8\r\nTRACKING\r\n$2\r\nON\r\n$0\r\n\r\n"
+
+	if b.String() != expected {
+		t.Errorf("write failed, wrong value: expected %q, got %q", expected, b.String())
+	}
+}
+
+func TestReadReply(t *testing.T) {
+	tests := map[string]string{
+		"+OK\r\n":                      "OK",
+		":42\r\n":                      "42",
+		"_\r\n":                        "<nil>",
+		"$-1\r\n":                      "<nil>",
+		"$3\r\nfoo\r\n":                "foo",
+		"-ERR unknown command\r\n":     "ERR unknown command",
+		"!3\r\nERR\r\n":                "ERR",
+		"*2\r\n+foo\r\n$3\r\nbar\r\n":  "[foo bar]",
+		"%1\r\n+server\r\n+redis\r\n":  "[server redis]",
+		"|1\r\n+ttl\r\n:3\r\n+foo\r\n": "foo",
+		">2\r\n$10\r\ninvalidate\r\n*1\r\n+foo\r\n": "[invalidate [foo]]",
+		">2\r\n$10\r\ninvalidate\r\n_\r\n":          "[invalidate <nil>]",
+	}
+
+	for data, expected := range tests {
+		reply, err := readReply(bufio.NewReader(strings.NewReader(data)))
+		if err != nil {
+			t.Errorf("read failed: expected nil for %q, got %v", data, err)
+		}
+
+		if res := fmt.Sprint(reply); res != expected {
+			t.Errorf("read failed, wrong value: expected %s, got %s", expected, res)
+		}
+	}
+
+	if reply, _ := readReply(bufio.NewReader(strings.NewReader(">1\r\n+foo\r\n"))); fmt.Sprintf("%T", reply) != "redis.push" {
+		t.Errorf("read failed: expected a push, got %T", reply)
+	}
+
+	if reply, _ := readReply(bufio.NewReader(strings.NewReader("-ERR\r\n"))); fmt.Sprintf("%T", reply) != "redis.replyError" {
+		t.Errorf("read failed: expected a reply error, got %T", reply)
+	}
+}
+
+func TestReadReplyFail(t *testing.T) {
+	tests := map[string]error{
+		"":                  io.EOF,
+		"+OK":               io.EOF,
+		"?\r\n":             errProtocol,
+		"+\n":               errProtocol,
+		"$x\r\n":            errProtocol,
+		"*x\r\n":            errProtocol,
+		"$3\r\nfo":          io.ErrUnexpectedEOF,
+		"*2\r\n+foo\r\n":    io.EOF,
+		"|1\r\n+ttl\r\n":    io.EOF,
+		"%1\r\n+server\r\n": io.EOF,
+	}
+
+	for data, expected := range tests {
+		if _, err := readReply(bufio.NewReader(strings.NewReader(data))); !errors.Is(err, expected) {
+			t.Errorf("read failed: expected %v for %q, got %v", expected, data, err)
+		}
+	}
+}
@@ -0,0 +1,641 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	rd "github.com/redis/go-redis/v9"
+
+	"github.com/faabiosr/cachego"
+)
+
+const (
+	// pingInterval is the interval of the PING keeping the tracking
+	// connection alive, it is considered lost after pingTimeout without reply
+	pingInterval = 5 * time.Second
+	pingTimeout  = 3 * pingInterval
+
+	// reconnectDelay is the delay between the attempts to track the keys
+	reconnectDelay = time.Second
+
+	// ttlPersistent is the PTTL of the keys without life time
+	ttlPersistent = -1
+)
+
+type (
+	tracking struct {
+		redis   *redis
+		tracker *tracker
+		owner   bool
+	}
+
+	// tracker keeps the local copies of the keys while a connection tracks
+	// them in BCAST mode, the copies are removed by the invalidations pushed
+	// by the server
+	tracker struct {
+		local  cachego.Cache
+		prefix string
+		dial   func(ctx context.Context) (net.Conn, error)
+		hello  func(ctx context.Context) ([]string, error)
+
+		// epoch changes on every invalidation, a value read before it
+		// changed is not kept
+		epoch atomic.Uint64
+		ready atomic.Bool
+
+		// lock serializes the local saves with the invalidations
+		lock sync.Mutex
+
+		mu     sync.Mutex
+		conn   net.Conn
+		done   chan struct{}
+		closed bool
+		wg     sync.WaitGroup
+	}
+)
+
+// newTracking creates the Redis cache driver keeping the local copies of the
+// keys read from the client
+func newTracking(r *redis, client *rd.Client) *tracking {
+	opt := client.Options()
+
+	t := newTracker(r.local, r.prefix, func(ctx context.Context) (net.Conn, error) {
+		return opt.Dialer(ctx, opt.Network, opt.Addr)
+	})
+
+	t.hello = func(ctx context.Context) ([]string, error) {
+		return hello(ctx, opt)
+	}
+
+	t.start()
+
+	return &tracking{redis: r, tracker: t, owner: true}
+}
+
+func newTracker(local cachego.Cache, prefix string, dial func(context.Context) (net.Conn, error)) *tracker {
+	return &tracker{
+		local:  local,
+		prefix: prefix,
+		dial:   dial,
+		hello: func(context.Context) ([]string, error) {
+			return []string{"HELLO", "3"}, nil
+		},
+		done: make(chan struct{}),
+	}
+}
+
+// hello returns the HELLO command switching the connection to RESP3, with the
+// credentials of the client
+func hello(ctx context.Context, opt *rd.Options) ([]string, error) {
+	username, password := opt.Username, opt.Password
+
+	switch {
+	case opt.CredentialsProviderContext != nil:
+		var err error
+
+		if username, password, err = opt.CredentialsProviderContext(ctx); err != nil {
+			return nil, err
+		}
+	case opt.CredentialsProvider != nil:
+		username, password = opt.CredentialsProvider()
+	}
+
+	if password == "" {
+		return []string{"HELLO", "3"}, nil
+	}
+
+	if username == "" {
+		username = "default"
+	}
+
+	return []string{"HELLO", "3", "AUTH", username, password}, nil
+}
+
+// start tracks the keys in the background until the tracker is closed,
+// reconnecting when the connection is lost
+func (t *tracker) start() {
+	t.wg.Add(1)
+
+	go func() {
+		defer t.wg.Done()
+
+		for {
+			_ = t.track()
+
+			// the invalidations are missed until the keys are tracked again
+			t.invalidateAll()
+
+			timer := time.NewTimer(reconnectDelay)
+
+			select {
+			case <-t.done:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+}
+
+// track connects to the server, enables the tracking of the keys and applies
+// the invalidations until the connection fails
+func (t *tracker) track() error {
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+
+	conn, err := t.dial(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	if !t.setConn(conn) {
+		return net.ErrClosed
+	}
+
+	r := bufio.NewReader(conn)
+	_ = conn.SetDeadline(time.Now().Add(pingTimeout))
+
+	hello, err := t.hello(ctx)
+	if err != nil {
+		return err
+	}
+
+	tracking := []string{"CLIENT", "TRACKING", "ON", "BCAST"}
+	if t.prefix != "" {
+		tracking = append(tracking, "PREFIX", t.prefix)
+	}
+
+	for _, cmd := range [][]string{hello, tracking} {
+		if err := writeCommand(conn, cmd...); err != nil {
+			return err
+		}
+
+		reply, err := readReply(r)
+		if err != nil {
+			return err
+		}
+
+		if err, ok := reply.(replyError); ok {
+			return err
+		}
+	}
+
+	t.invalidateAll()
+	t.ready.Store(true)
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go ping(conn, stop)
+
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(pingTimeout))
+
+		reply, err := readReply(r)
+		if err != nil {
+			return err
+		}
+
+		if p, ok := reply.(push); ok {
+			t.invalidate(p)
+		}
+	}
+}
+
+// ping writes a PING every interval until stopped, the replies are read
+// along with the invalidations
+func ping(conn net.Conn, stop chan struct{}) {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(pingInterval))
+
+			if err := writeCommand(conn, "PING"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// setConn keeps the connection to close it along with the tracker
+func (t *tracker) setConn(conn net.Conn) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return false
+	}
+
+	t.conn = conn
+
+	return true
+}
+
+// close stops the tracking of the keys
+func (t *tracker) close() {
+	t.mu.Lock()
+
+	if t.closed {
+		t.mu.Unlock()
+		return
+	}
+
+	t.closed = true
+	close(t.done)
+
+	if t.conn != nil {
+		_ = t.conn.Close()
+	}
+
+	t.mu.Unlock()
+	t.wg.Wait()
+}
+
+// invalidate removes the local copies of the keys of the invalidation, a null
+// list of keys is sent when the database is flushed
+func (t *tracker) invalidate(p push) {
+	if len(p) != 2 || p[0] != "invalidate" {
+		return
+	}
+
+	keys, ok := p[1].([]any)
+	if !ok {
+		t.flush()
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.epoch.Add(1)
+
+	for _, key := range keys {
+		if k, ok := key.(string); ok {
+			_ = t.local.Delete(k)
+		}
+	}
+}
+
+// invalidateAll removes all the local copies, they are not read until the
+// keys are tracked
+func (t *tracker) invalidateAll() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.ready.Store(false)
+	t.epoch.Add(1)
+	_ = t.local.Flush()
+}
+
+// flush removes all the local copies, keeping the keys tracked
+func (t *tracker) flush() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.epoch.Add(1)
+	_ = t.local.Flush()
+}
+
+// forget removes the local copies of the keys written by the driver, without
+// waiting for their invalidation
+func (t *tracker) forget(keys ...string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.epoch.Add(1)
+
+	for _, key := range keys {
+		_ = t.local.Delete(key)
+	}
+}
+
+// store keeps the local copy of a value read from the server, unless it was
+// invalidated since the epoch
+func (t *tracker) store(epoch uint64, key, value string, ttl time.Duration) {
+	switch {
+	case ttl == ttlPersistent:
+		ttl = 0
+	case ttl <= 0:
+		// the key is missing or about to expire
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.ready.Load() && t.epoch.Load() == epoch {
+		_ = t.local.Save(key, value, ttl)
+	}
+}
+
+// cached reads the local copy of the key, while the keys are tracked and the
+// context is not done
+func (t *tracking) cached(ctx context.Context, key string) (string, bool) {
+	if !t.tracker.ready.Load() || ctx.Err() != nil {
+		return "", false
+	}
+
+	value, err := t.tracker.local.Fetch(t.redis.prefix + key)
+
+	return value, err == nil
+}
+
+// load reads the keys from Redis keeping the local copies of the values, the
+// error is the first one besides the missing keys
+func (t *tracking) load(ctx context.Context, keys []string) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return map[string]string{}, err
+	}
+
+	epoch := t.tracker.epoch.Load()
+	gets := make([]*rd.StringCmd, len(keys))
+	ttls := make([]*rd.DurationCmd, len(keys))
+
+	_, _ = t.redis.driver.Pipelined(ctx, func(pipe rd.Pipeliner) error {
+		for i, key := range keys {
+			gets[i] = pipe.Get(ctx, t.redis.prefix+key)
+			ttls[i] = pipe.PTTL(ctx, t.redis.prefix+key)
+		}
+
+		return nil
+	})
+
+	result := make(map[string]string)
+
+	var failure error
+
+	for i, key := range keys {
+		value, err := gets[i].Result()
+		if err != nil {
+			if err = cacheErr(err); failure == nil && !errors.Is(err, cachego.ErrCacheMiss) {
+				failure = err
+			}
+
+			continue
+		}
+
+		result[key] = value
+		t.tracker.store(epoch, t.redis.prefix+key, value, ttls[i].Val())
+	}
+
+	return result, failure
+}
+
+// forget removes the local copies of the keys
+func (t *tracking) forget(keys ...string) {
+	prefixed := make([]string, len(keys))
+
+	for i, key := range keys {
+		prefixed[i] = t.redis.prefix + key
+	}
+
+	t.tracker.forget(prefixed...)
+}
+
+// Close stops the tracking of the keys, the namespaces share the tracking of
+// their cache and their Close does nothing
+func (t *tracking) Close() error {
+	if t.owner {
+		t.tracker.close()
+	}
+
+	return nil
+}
+
+// Contains checks if cached key exists in the local copies or Redis storage
+func (t *tracking) Contains(key string) bool {
+	return t.ContainsContext(context.Background(), key)
+}
+
+// ContainsContext checks if cached key exists in the local copies or Redis storage
+func (t *tracking) ContainsContext(ctx context.Context, key string) bool {
+	if _, ok := t.cached(ctx, key); ok {
+		return true
+	}
+
+	return t.redis.ContainsContext(ctx, key)
+}
+
+// Delete the cached key from Redis storage and its local copy
+func (t *tracking) Delete(key string) error {
+	return t.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext the cached key from Redis storage and its local copy
+func (t *tracking) DeleteContext(ctx context.Context, key string) error {
+	defer t.forget(key)
+	return t.redis.DeleteContext(ctx, key)
+}
+
+// DeleteMulti removes multiple cached keys from Redis storage and their local copies
+func (t *tracking) DeleteMulti(keys []string) error {
+	defer t.forget(keys...)
+	return t.redis.DeleteMulti(keys)
+}
+
+// Fetch retrieves the cached value from key of the local copies or Redis storage
+func (t *tracking) Fetch(key string) (string, error) {
+	return t.FetchContext(context.Background(), key)
+}
+
+// FetchContext retrieves the cached value from key of the local copies or Redis storage
+func (t *tracking) FetchContext(ctx context.Context, key string) (string, error) {
+	if value, ok := t.cached(ctx, key); ok {
+		return value, nil
+	}
+
+	values, err := t.load(ctx, []string{key})
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := values[key]
+	if !ok {
+		return "", cachego.ErrCacheMiss
+	}
+
+	return value, nil
+}
+
+// FetchBytes retrieves the cached binary value from key of the local copies or Redis storage
+func (t *tracking) FetchBytes(key string) ([]byte, error) {
+	value, err := t.FetchContext(context.Background(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(value), nil
+}
+
+// FetchMulti retrieves multiple cached value from keys of the local copies or Redis storage
+func (t *tracking) FetchMulti(keys []string) map[string]string {
+	return t.FetchMultiContext(context.Background(), keys)
+}
+
+// FetchMultiContext retrieves multiple cached value from keys of the local
+// copies or Redis storage, the missing copies are read in a pipeline
+func (t *tracking) FetchMultiContext(ctx context.Context, keys []string) map[string]string {
+	result := make(map[string]string)
+	missing := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		if value, ok := t.cached(ctx, key); ok {
+			result[key] = value
+			continue
+		}
+
+		missing = append(missing, key)
+	}
+
+	if len(missing) == 0 {
+		return result
+	}
+
+	values, _ := t.load(ctx, missing)
+
+	for key, value := range values {
+		result[key] = value
+	}
+
+	return result
+}
+
+// FetchMultiBytes retrieves multiple cached binary value from keys of the local copies or Redis storage
+func (t *tracking) FetchMultiBytes(keys []string) map[string][]byte {
+	result := make(map[string][]byte)
+
+	for key, value := range t.FetchMulti(keys) {
+		result[key] = []byte(value)
+	}
+
+	return result
+}
+
+// Flush removes all cached keys of the Redis storage and all the local copies
+func (t *tracking) Flush() error {
+	return t.FlushContext(context.Background())
+}
+
+// FlushContext removes all cached keys of the Redis storage and all the local copies
+func (t *tracking) FlushContext(ctx context.Context) error {
+	defer t.tracker.flush()
+	return t.redis.FlushContext(ctx)
+}
+
+// Namespace returns a view of the Redis storage that prefixes all the keys
+// by the prefix and a colon, sharing the local copies and their tracking
+func (t *tracking) Namespace(prefix string) cachego.Cache {
+	r, _ := t.redis.Namespace(prefix).(*redis)
+	return &tracking{redis: r, tracker: t.tracker}
+}
+
+// Save a value in Redis storage by key, removing its local copy
+func (t *tracking) Save(key string, value string, lifeTime time.Duration) error {
+	return t.SaveContext(context.Background(), key, value, lifeTime)
+}
+
+// SaveContext a value in Redis storage by key, removing its local copy
+func (t *tracking) SaveContext(ctx context.Context, key string, value string, lifeTime time.Duration) error {
+	defer t.forget(key)
+	return t.redis.SaveContext(ctx, key, value, lifeTime)
+}
+
+// SaveBytes a binary value in Redis storage by key, removing its local copy
+func (t *tracking) SaveBytes(key string, value []byte, lifeTime time.Duration) error {
+	defer t.forget(key)
+	return t.redis.SaveBytes(key, value, lifeTime)
+}
+
+// SaveMulti multiple values in Redis storage by their keys, removing their local copies
+func (t *tracking) SaveMulti(values map[string]string, lifeTime time.Duration) error {
+	keys := make([]string, 0, len(values))
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	defer t.forget(keys...)
+
+	return t.redis.SaveMulti(values, lifeTime)
+}
+
+// FetchWithVersion retrieves the cached value from key of the Redis storage
+// along with its version, the local copies are not used
+func (t *tracking) FetchWithVersion(key string) (string, string, error) {
+	return t.redis.FetchWithVersion(key)
+}
+
+// SaveIfVersion a value in Redis storage by key, only when its version is
+// still the given one, removing its local copy
+func (t *tracking) SaveIfVersion(key, value, version string, lifeTime time.Duration) error {
+	defer t.forget(key)
+	return t.redis.SaveIfVersion(key, value, version, lifeTime)
+}
+
+// DeleteIfVersion removes the cached key from Redis storage and its local
+// copy, only when its version is still the given one
+func (t *tracking) DeleteIfVersion(key, version string) error {
+	defer t.forget(key)
+	return t.redis.DeleteIfVersion(key, version)
+}
+
+// SaveIfAbsent a value in Redis storage by key, only when the key is missing,
+// removing its local copy
+func (t *tracking) SaveIfAbsent(key string, value string, lifeTime time.Duration) (bool, error) {
+	defer t.forget(key)
+	return t.redis.SaveIfAbsent(key, value, lifeTime)
+}
+
+// Replace a value in Redis storage by key, only when the key exists, removing
+// its local copy
+func (t *tracking) Replace(key string, value string, lifeTime time.Duration) (bool, error) {
+	defer t.forget(key)
+	return t.redis.Replace(key, value, lifeTime)
+}
+
+// Touch updates the life time of the cached key in Redis storage, removing
+// its local copy kept with the previous life time
+func (t *tracking) Touch(key string, lifeTime time.Duration) error {
+	defer t.forget(key)
+	return t.redis.Touch(key, lifeTime)
+}
+
+// Incr increments the value of the cached key in Redis storage, removing its
+// local copy
+func (t *tracking) Incr(key string, delta int64) (int64, error) {
+	defer t.forget(key)
+	return t.redis.Incr(key, delta)
+}
+
+// Decr decrements the value of the cached key in Redis storage, removing its
+// local copy
+func (t *tracking) Decr(key string, delta int64) (int64, error) {
+	defer t.forget(key)
+	return t.redis.Decr(key, delta)
+}
+
+// CompareAndDelete removes the cached key from Redis storage and its local
+// copy, only while it holds the value
+func (t *tracking) CompareAndDelete(ctx context.Context, key, value string) (bool, error) {
+	defer t.forget(key)
+	return t.redis.CompareAndDelete(ctx, key, value)
+}
+
+// CompareAndExpire updates the life time of the cached key in Redis storage
+// only while it holds the value, removing its local copy
+func (t *tracking) CompareAndExpire(ctx context.Context, key, value string, lifeTime time.Duration) (bool, error) {
+	defer t.forget(key)
+	return t.redis.CompareAndExpire(ctx, key, value, lifeTime)
+}
@@ -0,0 +1,390 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	rd "github.com/redis/go-redis/v9"
+
+	"github.com/faabiosr/cachego"
+	"github.com/faabiosr/cachego/cachegotest"
+	"github.com/faabiosr/cachego/memory"
+)
+
+// peer is a stand-in of the server side of the tracking connection, it
+// replies to the commands and pushes the invalidations
+type peer struct {
+	mu   sync.Mutex
+	conn net.Conn
+	cmds chan string
+}
+
+func (p *peer) serve() {
+	r := bufio.NewReader(p.conn)
+
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+
+		cmd := strings.Trim(fmt.Sprint(reply), "[]")
+
+		switch {
+		case strings.HasPrefix(cmd, "HELLO"):
+			p.write("%1\r\n+server\r\n+redis\r\n")
+		case strings.HasPrefix(cmd, "PING"):
+			p.write("+PONG\r\n")
+		default:
+			p.write("+OK\r\n")
+		}
+
+		select {
+		case p.cmds <- cmd:
+		default:
+		}
+	}
+}
+
+func (p *peer) write(data string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, _ = p.conn.Write([]byte(data))
+}
+
+// invalidate pushes the invalidation of the keys, all of them without keys
+func (p *peer) invalidate(keys ...string) {
+	if len(keys) == 0 {
+		p.write(">2\r\n$10\r\ninvalidate\r\n_\r\n")
+		return
+	}
+
+	data := fmt.Sprintf(">2\r\n$10\r\ninvalidate\r\n*%d\r\n", len(keys))
+	for _, key := range keys {
+		data += fmt.Sprintf("$%d\r\n%s\r\n", len(key), key)
+	}
+
+	p.write(data)
+}
+
+// newTracked creates the tracking driver on a miniredis server, its tracking
+// connections are served by the peers sent to the channel
+func newTracked(t *testing.T, prefix string) (*tracking, *miniredis.Miniredis, chan *peer) {
+	t.Helper()
+
+	m := runMiniredis(t)
+	peers := make(chan *peer, 4)
+
+	client := rd.NewClient(&rd.Options{Addr: m.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	tr := newTracker(memory.New(), prefix, func(context.Context) (net.Conn, error) {
+		conn, server := net.Pipe()
+		p := &peer{conn: server, cmds: make(chan string, 8)}
+
+		go p.serve()
+		peers <- p
+
+		return conn, nil
+	})
+
+	tr.start()
+
+	c := &tracking{redis: &redis{driver: client, prefix: prefix}, tracker: tr, owner: true}
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c, m, peers
+}
+
+// eventually waits for the condition to be met
+func eventually(t *testing.T, condition func() bool) bool {
+	t.Helper()
+
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); {
+		if condition() {
+			return true
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	return false
+}
+
+func TestTracking(t *testing.T) {
+	c, m, peers := newTracked(t, "cachego:")
+	p := <-peers
+
+	if cmd := <-p.cmds; cmd != "HELLO 3" {
+		t.Errorf("tracking failed, wrong command: expected %s, got %s", "HELLO 3", cmd)
+	}
+
+	if cmd := <-p.cmds; cmd != "CLIENT TRACKING ON BCAST PREFIX cachego:" {
+		t.Errorf("tracking failed, wrong command: expected %s, got %s", "CLIENT TRACKING ON BCAST PREFIX cachego:", cmd)
+	}
+
+	if !eventually(t, c.tracker.ready.Load) {
+		t.Fatal("tracking failed: the keys should be tracked")
+	}
+
+	if err := c.Save(testKey, testValue, 0); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	// another service writes the key, the local copy is served until the
+	// server invalidates it
+	_ = m.Set("cachego:"+testKey, "baz")
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value from the local copy: expected %s, got %s", testValue, res)
+	}
+
+	p.invalidate("cachego:" + testKey)
+
+	if !eventually(t, func() bool { res, _ := c.Fetch(testKey); return res == "baz" }) {
+		t.Errorf("invalidate failed: expected %s", "baz")
+	}
+
+	values := c.FetchMulti([]string{testKey, "missing"})
+	if len(values) != 1 || values[testKey] != "baz" {
+		t.Errorf("fetch multi failed, wrong value: expected %s, got %v", "baz", values)
+	}
+
+	_ = m.Set("cachego:"+testKey, "qux")
+	p.invalidate()
+
+	if !eventually(t, func() bool { return c.FetchMulti([]string{testKey})[testKey] == "qux" }) {
+		t.Errorf("invalidate failed: expected %s", "qux")
+	}
+
+	// the writes of the driver remove the local copies right away
+	if err := c.Delete(testKey); err != nil {
+		t.Errorf("delete failed: expected nil, got %v", err)
+	}
+
+	if c.Contains(testKey) {
+		t.Errorf("contains failed: the key %s should not be exist", testKey)
+	}
+}
+
+func TestTrackingExpiration(t *testing.T) {
+	c, _, _ := newTracked(t, "")
+
+	if !eventually(t, c.tracker.ready.Load) {
+		t.Fatal("tracking failed: the keys should be tracked")
+	}
+
+	_ = c.Save(testKey, testValue, 100*time.Millisecond)
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	if !c.tracker.local.Contains(testKey) {
+		t.Errorf("fetch failed: the local copy of %s should be exist", testKey)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+
+	if c.tracker.local.Contains(testKey) {
+		t.Errorf("fetch failed: the local copy of %s should be expired", testKey)
+	}
+}
+
+func TestTrackingReconnect(t *testing.T) {
+	c, m, peers := newTracked(t, "")
+	p := <-peers
+
+	if !eventually(t, c.tracker.ready.Load) {
+		t.Fatal("tracking failed: the keys should be tracked")
+	}
+
+	_ = c.Save(testKey, testValue, 0)
+	_, _ = c.Fetch(testKey)
+	_ = p.conn.Close()
+
+	if !eventually(t, func() bool { return !c.tracker.ready.Load() }) {
+		t.Fatal("tracking failed: the keys should not be tracked")
+	}
+
+	// the invalidations are missed while the connection is down
+	_ = m.Set(testKey, "baz")
+
+	if res, _ := c.Fetch(testKey); res != "baz" {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", "baz", res)
+	}
+
+	if c.tracker.local.Contains(testKey) {
+		t.Errorf("fetch failed: the local copy of %s should not be kept", testKey)
+	}
+
+	select {
+	case <-peers:
+	case <-time.After(2 * reconnectDelay):
+		t.Fatal("tracking failed: expected a new connection")
+	}
+
+	if !eventually(t, c.tracker.ready.Load) {
+		t.Errorf("tracking failed: the keys should be tracked again")
+	}
+}
+
+func TestTrackingNamespace(t *testing.T) {
+	c, _, _ := newTracked(t, "cachego:")
+	ns := c.Namespace("users")
+
+	if !eventually(t, c.tracker.ready.Load) {
+		t.Fatal("tracking failed: the keys should be tracked")
+	}
+
+	_ = ns.Save(testKey, testValue, 0)
+
+	if res, _ := ns.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	if !c.tracker.local.Contains("cachego:users:" + testKey) {
+		t.Errorf("fetch failed: the local copy of %s should be exist", "cachego:users:"+testKey)
+	}
+
+	if err := ns.(*tracking).Close(); err != nil || !c.tracker.ready.Load() {
+		t.Errorf("close failed: the namespace should not stop the tracking, got %v", err)
+	}
+}
+
+func TestTrackingCAS(t *testing.T) {
+	c, _, _ := newTracked(t, "cachego:")
+
+	if !eventually(t, c.tracker.ready.Load) {
+		t.Fatal("tracking failed: the keys should be tracked")
+	}
+
+	_ = c.Save(testKey, testValue, 0)
+	_, version, _ := c.FetchWithVersion(testKey)
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	if err := c.SaveIfVersion(testKey, "baz", version, 0); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.Fetch(testKey); res != "baz" {
+		t.Errorf("fetch fail, wrong value: expected the local copy removed %s, got %s", "baz", res)
+	}
+
+	_, version, _ = c.FetchWithVersion(testKey)
+
+	if err := c.DeleteIfVersion(testKey, version); err != nil {
+		t.Errorf("delete failed: expected nil, got %v", err)
+	}
+
+	if c.Contains(testKey) {
+		t.Errorf("contains failed: the key %s should not be exist", testKey)
+	}
+}
+
+func TestTrackingAtomic(t *testing.T) {
+	c, _, _ := newTracked(t, "cachego:")
+
+	if !eventually(t, c.tracker.ready.Load) {
+		t.Fatal("tracking failed: the keys should be tracked")
+	}
+
+	_ = c.Save(testKey, "1", 0)
+
+	if res, _ := c.Fetch(testKey); res != "1" {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", "1", res)
+	}
+
+	if n, err := c.Incr(testKey, 1); n != 2 || err != nil {
+		t.Errorf("incr failed: expected %d, got %d (%v)", 2, n, err)
+	}
+
+	if res, _ := c.Fetch(testKey); res != "2" {
+		t.Errorf("fetch fail, wrong value: expected the local copy removed %s, got %s", "2", res)
+	}
+
+	if ok, _ := c.Replace(testKey, testValue, 0); !ok {
+		t.Errorf("replace failed: the key %s should be replaced", testKey)
+	}
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected the local copy removed %s, got %s", testValue, res)
+	}
+
+	if err := c.Touch(testKey, 1*time.Millisecond); err != nil {
+		t.Errorf("touch failed: expected nil, got %v", err)
+	}
+
+	if c.tracker.local.Contains("cachego:" + testKey) {
+		t.Errorf("touch failed: the local copy of %s should be removed", testKey)
+	}
+}
+
+func TestTrackingSuite(t *testing.T) {
+	cachegotest.RunSuite(t, func(t *testing.T) cachego.Cache {
+		c, _, _ := newTracked(t, "")
+		eventually(t, c.tracker.ready.Load)
+
+		return c
+	})
+}
+
+func TestHello(t *testing.T) {
+	tests := []struct {
+		opt      *rd.Options
+		expected string
+	}{
+		{&rd.Options{}, "[HELLO 3]"},
+		{&rd.Options{Password: "secret"}, "[HELLO 3 AUTH default secret]"},
+		{&rd.Options{Username: "cache", Password: "secret"}, "[HELLO 3 AUTH cache secret]"},
+		{&rd.Options{CredentialsProvider: func() (string, string) { return "cache", "provided" }}, "[HELLO 3 AUTH cache provided]"},
+	}
+
+	for _, test := range tests {
+		if cmd, _ := hello(context.Background(), test.opt); fmt.Sprint(cmd) != test.expected {
+			t.Errorf("hello failed, wrong value: expected %s, got %v", test.expected, cmd)
+		}
+	}
+}
+
+func TestRedisTracking(t *testing.T) {
+	if _, err := net.Dial("tcp", "localhost:6379"); err != nil {
+		t.Skip(err)
+	}
+
+	conn := rd.NewClient(&rd.Options{Addr: ":6379"})
+	c := New(conn, WithPrefix("cachego:tracking:"), WithTracking(memory.New()))
+
+	t.Cleanup(func() { _ = c.(*tracking).Close() })
+
+	if !eventually(t, c.(*tracking).tracker.ready.Load) {
+		t.Skip("the server does not support the client-side caching")
+	}
+
+	_ = c.Save(testKey, testValue, 10*time.Second)
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	_ = conn.Set(context.Background(), "cachego:tracking:"+testKey, "baz", 10*time.Second).Err()
+
+	if !eventually(t, func() bool { res, _ := c.Fetch(testKey); return res == "baz" }) {
+		t.Errorf("invalidate failed: expected %s", "baz")
+	}
+}
@@ -0,0 +1,89 @@
+package cachego
+
+import (
+	"sync"
+	"time"
+)
+
+// Revalidator wraps a cache serving the values past their stale time while
+// a background refresh reloads them, only the life time removes the values.
+type Revalidator struct {
+	StaleCache
+
+	staleTime  time.Duration
+	lifeTime   time.Duration
+	load       func(key string) (string, error)
+	refreshing sync.Map
+}
+
+// NewRevalidator creates an instance of Revalidator, the values become stale
+// after the stale time and are refreshed by load, being saved again with the
+// stale time and life time.
+func NewRevalidator(
+	cache Cache,
+	staleTime, lifeTime time.Duration,
+	load func(key string) (string, error),
+) *Revalidator {
+	return &Revalidator{
+		StaleCache: NewStaleCache(cache),
+		staleTime:  staleTime,
+		lifeTime:   lifeTime,
+		load:       load,
+	}
+}
+
+// Contains checks if the cached key exists, stale or not
+func (r *Revalidator) Contains(key string) bool {
+	_, err := r.Fetch(key)
+	return err == nil
+}
+
+// Fetch retrieves the cached key value, a stale value is returned while
+// its refresh runs in the background
+func (r *Revalidator) Fetch(key string) (string, error) {
+	value, stale, err := r.FetchStale(key)
+	if err != nil {
+		return "", err
+	}
+
+	if stale {
+		r.refresh(key)
+	}
+
+	return value, nil
+}
+
+// FetchMulti retrieves multiple cached keys value, the stale ones are
+// refreshed in the background
+func (r *Revalidator) FetchMulti(keys []string) map[string]string {
+	result := make(map[string]string)
+
+	for _, key := range keys {
+		if value, err := r.Fetch(key); err == nil {
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+// Save caches a value by key that becomes stale after the stale time
+func (r *Revalidator) Save(key string, value string, lifeTime time.Duration) error {
+	return r.SaveStale(key, value, r.staleTime, lifeTime)
+}
+
+// refresh reloads the key in the background, at most once at a time, a
+// failed refresh keeps the stale value until its life time
+func (r *Revalidator) refresh(key string) {
+	if _, running := r.refreshing.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+
+	go func() {
+		defer r.refreshing.Delete(key)
+
+		if value, err := r.load(key); err == nil {
+			_ = r.SaveStale(key, value, r.staleTime, r.lifeTime)
+		}
+	}()
+}
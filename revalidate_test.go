@@ -0,0 +1,84 @@
+package cachego
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRevalidator(t *testing.T) {
+	var calls int32
+
+	release := make(chan struct{})
+
+	r := NewRevalidator(lockedCache{mapCache{}, &sync.Mutex{}}, 1*time.Nanosecond, 0, func(string) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "baz", nil
+	})
+
+	if err := r.Save("foo", "bar", 0); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if res, _ := r.Fetch("foo"); res != "bar" {
+			t.Errorf("fetch fail, wrong value: expected %s, got %s", "bar", res)
+		}
+	}
+
+	if values := r.FetchMulti([]string{"foo", "baz"}); len(values) != 1 {
+		t.Errorf("fetch multi failed: expected %d, got %d", 1, len(values))
+	}
+
+	close(release)
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); {
+		if res, _, _ := r.FetchStale("foo"); res == "baz" {
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	if res, _, _ := r.FetchStale("foo"); res != "baz" {
+		t.Errorf("refresh fail, wrong value: expected %s, got %s", "baz", res)
+	}
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("refresh failed: expected %d load, got %d", 1, n)
+	}
+
+	if !r.Contains("foo") {
+		t.Errorf("contains failed: the key %s should be exist", "foo")
+	}
+
+	if _, err := r.Fetch("baz"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", ErrCacheMiss, err)
+	}
+}
+
+func TestRevalidatorFailure(t *testing.T) {
+	var calls int32
+
+	r := NewRevalidator(lockedCache{mapCache{}, &sync.Mutex{}}, 1*time.Nanosecond, 0, func(string) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		return "", errors.New("unable to load")
+	})
+
+	_ = r.Save("foo", "bar", 0)
+
+	if res, _ := r.Fetch("foo"); res != "bar" {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", "bar", res)
+	}
+
+	for deadline := time.Now().Add(time.Second); atomic.LoadInt32(&calls) == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+
+	if res, _ := r.Fetch("foo"); res != "bar" {
+		t.Errorf("fetch fail, wrong value: expected the stale %s, got %s", "bar", res)
+	}
+}
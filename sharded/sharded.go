@@ -0,0 +1,344 @@
+// Package sharded providers a cache driver that stores the cache in memory,
+// spreading the keys over shards guarded by their own lock to reduce the
+// contention of concurrent writes.
+package sharded
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/faabiosr/cachego"
+)
+
+type (
+	// item keeps the expiration and stale time in Unix nanoseconds
+	item struct {
+		data     []byte
+		duration int64
+		stale    int64
+	}
+
+	shard struct {
+		sync.RWMutex
+		items map[string]*item
+	}
+
+	sharded struct {
+		shards   []*shard
+		prefix   string
+		clock    cachego.Clock
+		interval time.Duration
+		janitor  *cachego.Janitor
+	}
+
+	// Option configures the Sharded cache driver
+	Option func(*sharded)
+)
+
+const (
+	defaultShards = 32
+
+	fnvOffset = 2166136261
+	fnvPrime  = 16777619
+)
+
+// New creates an instance of Sharded cache driver
+func New(opts ...Option) cachego.Cache {
+	s := &sharded{
+		shards: make([]*shard, defaultShards),
+		clock:  cachego.SystemClock{},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	for i := range s.shards {
+		s.shards[i] = &shard{items: make(map[string]*item)}
+	}
+
+	if s.interval > 0 {
+		s.janitor = cachego.NewJanitor(s, s.interval)
+	}
+
+	return s
+}
+
+// WithShards sets the number of shards, 32 by default
+func WithShards(n int) Option {
+	return func(s *sharded) {
+		if n > 0 {
+			s.shards = make([]*shard, n)
+		}
+	}
+}
+
+// WithClock sets the clock used to compute the expiration of the keys
+func WithClock(clock cachego.Clock) Option {
+	return func(s *sharded) {
+		s.clock = clock
+	}
+}
+
+// WithJanitor purges the expired keys in the background every interval,
+// until the cache is closed
+func WithJanitor(interval time.Duration) Option {
+	return func(s *sharded) {
+		s.interval = interval
+	}
+}
+
+// shard returns the shard of the key, hashed with 32-bit FNV-1a
+func (s *sharded) shard(key string) *shard {
+	hash := uint32(fnvOffset)
+
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= fnvPrime
+	}
+
+	return s.shards[hash%uint32(len(s.shards))]
+}
+
+func (s *sharded) read(key string) (*item, error) {
+	key = s.prefix + key
+	sh := s.shard(key)
+
+	sh.RLock()
+	it, ok := sh.items[key]
+	sh.RUnlock()
+
+	if !ok {
+		return nil, cachego.ErrCacheMiss
+	}
+
+	if it.duration > 0 && it.duration <= s.clock.Now().UnixNano() {
+		sh.Lock()
+
+		// the key may have been saved again since it was read
+		if sh.items[key] == it {
+			delete(sh.items, key)
+		}
+
+		sh.Unlock()
+
+		return nil, cachego.ErrCacheExpired
+	}
+
+	return it, nil
+}
+
+// Close stops the janitor of the Sharded storage
+func (s *sharded) Close() error {
+	if s.janitor != nil {
+		s.janitor.Stop()
+	}
+
+	return nil
+}
+
+// Contains checks if cached key exists in Sharded storage
+func (s *sharded) Contains(key string) bool {
+	return s.ContainsContext(context.Background(), key)
+}
+
+// ContainsContext checks if cached key exists in Sharded storage
+func (s *sharded) ContainsContext(ctx context.Context, key string) bool {
+	_, err := s.FetchContext(ctx, key)
+	return err == nil
+}
+
+// Delete the cached key from Sharded storage
+func (s *sharded) Delete(key string) error {
+	return s.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext the cached key from Sharded storage
+func (s *sharded) DeleteContext(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	key = s.prefix + key
+	sh := s.shard(key)
+
+	sh.Lock()
+	delete(sh.items, key)
+	sh.Unlock()
+
+	return nil
+}
+
+// Fetch retrieves the cached value from key of the Sharded storage
+func (s *sharded) Fetch(key string) (string, error) {
+	return s.FetchContext(context.Background(), key)
+}
+
+// FetchContext retrieves the cached value from key of the Sharded storage
+func (s *sharded) FetchContext(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	it, err := s.read(key)
+	if err != nil {
+		return "", err
+	}
+
+	return string(it.data), nil
+}
+
+// FetchBytes retrieves the cached binary value from key of the Sharded storage
+func (s *sharded) FetchBytes(key string) ([]byte, error) {
+	it, err := s.read(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.Clone(it.data), nil
+}
+
+// FetchStale retrieves the cached value from key of the Sharded storage and
+// whether it is stale
+func (s *sharded) FetchStale(key string) (string, bool, error) {
+	it, err := s.read(key)
+	if err != nil {
+		return "", false, err
+	}
+
+	return string(it.data), it.stale > 0 && it.stale <= s.clock.Now().UnixNano(), nil
+}
+
+// FetchMulti retrieves multiple cached value from keys of the Sharded storage
+func (s *sharded) FetchMulti(keys []string) map[string]string {
+	return s.FetchMultiContext(context.Background(), keys)
+}
+
+// FetchMultiContext retrieves multiple cached value from keys of the Sharded storage
+func (s *sharded) FetchMultiContext(ctx context.Context, keys []string) map[string]string {
+	result := make(map[string]string)
+
+	for _, key := range keys {
+		if value, err := s.FetchContext(ctx, key); err == nil {
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+// FetchMultiBytes retrieves multiple cached binary value from keys of the Sharded storage
+func (s *sharded) FetchMultiBytes(keys []string) map[string][]byte {
+	result := make(map[string][]byte)
+
+	for _, key := range keys {
+		if value, err := s.FetchBytes(key); err == nil {
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+// Flush removes all cached keys of the Sharded storage
+func (s *sharded) Flush() error {
+	return s.FlushContext(context.Background())
+}
+
+// FlushContext removes all cached keys of the Sharded storage
+func (s *sharded) FlushContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for _, sh := range s.shards {
+		sh.Lock()
+
+		for key := range sh.items {
+			if strings.HasPrefix(key, s.prefix) {
+				delete(sh.items, key)
+			}
+		}
+
+		sh.Unlock()
+	}
+
+	return nil
+}
+
+// Namespace returns a view of the Sharded storage that prefixes all the keys
+// by the prefix and a colon, sharing the shards without its janitor
+func (s *sharded) Namespace(prefix string) cachego.Cache {
+	return &sharded{shards: s.shards, prefix: s.prefix + prefix + ":", clock: s.clock}
+}
+
+// PurgeExpired removes the expired keys of the Sharded storage
+func (s *sharded) PurgeExpired() (int, error) {
+	now := s.clock.Now().UnixNano()
+	purged := 0
+
+	for _, sh := range s.shards {
+		sh.Lock()
+
+		for key, it := range sh.items {
+			if it.duration > 0 && it.duration <= now {
+				delete(sh.items, key)
+				purged++
+			}
+		}
+
+		sh.Unlock()
+	}
+
+	return purged, nil
+}
+
+// Save a value in Sharded storage by key
+func (s *sharded) Save(key string, value string, lifeTime time.Duration) error {
+	return s.SaveContext(context.Background(), key, value, lifeTime)
+}
+
+// SaveContext a value in Sharded storage by key
+func (s *sharded) SaveContext(ctx context.Context, key string, value string, lifeTime time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.store(key, []byte(value), 0, lifeTime)
+	return nil
+}
+
+// SaveBytes a binary value in Sharded storage by key
+func (s *sharded) SaveBytes(key string, value []byte, lifeTime time.Duration) error {
+	s.store(key, bytes.Clone(value), 0, lifeTime)
+	return nil
+}
+
+// SaveStale a value in Sharded storage by key that becomes stale after the stale time
+func (s *sharded) SaveStale(key string, value string, staleTime, lifeTime time.Duration) error {
+	s.store(key, []byte(value), staleTime, lifeTime)
+	return nil
+}
+
+func (s *sharded) store(key string, data []byte, staleTime, lifeTime time.Duration) {
+	now := s.clock.Now()
+	it := &item{data: data}
+
+	if lifeTime > 0 {
+		it.duration = now.Add(lifeTime).UnixNano()
+	}
+
+	if staleTime > 0 {
+		it.stale = now.Add(staleTime).UnixNano()
+	}
+
+	key = s.prefix + key
+	sh := s.shard(key)
+
+	sh.Lock()
+	sh.items[key] = it
+	sh.Unlock()
+}
@@ -0,0 +1,232 @@
+package sharded
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/faabiosr/cachego"
+	"github.com/faabiosr/cachego/cachegotest"
+	syncmap "github.com/faabiosr/cachego/sync"
+)
+
+const (
+	testKey   = "foo"
+	testValue = "bar"
+)
+
+func TestSharded(t *testing.T) {
+	c := New()
+
+	if err := c.Save(testKey, testValue, 1*time.Nanosecond); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if _, err := c.Fetch(testKey); err == nil {
+		t.Errorf("fetch fail: expected an error, got %v", err)
+	}
+
+	_ = c.Save(testKey, testValue, 10*time.Second)
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	_ = c.Save(testKey, testValue, 0)
+
+	if !c.Contains(testKey) {
+		t.Errorf("contains failed: the key %s should be exist", testKey)
+	}
+
+	_ = c.Save("bar", testValue, 0)
+
+	if values := c.FetchMulti([]string{testKey, "bar"}); len(values) != 2 {
+		t.Errorf("fetch multi failed: expected %d, got %d", 2, len(values))
+	}
+
+	if err := c.Flush(); err != nil {
+		t.Errorf("flush failed: expected nil, got %v", err)
+	}
+
+	if c.Contains(testKey) {
+		t.Errorf("contains failed: the key %s should not be exist", testKey)
+	}
+}
+
+func TestShardedBytes(t *testing.T) {
+	c := New().(cachego.BytesCache)
+	value := []byte{0x00, 0xff, 0xfe, '"', '\\'}
+
+	if err := c.SaveBytes(testKey, value, 0); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.FetchBytes(testKey); !bytes.Equal(res, value) {
+		t.Errorf("fetch fail, wrong value: expected %v, got %v", value, res)
+	}
+
+	if values := c.FetchMultiBytes([]string{testKey, "bar"}); !bytes.Equal(values[testKey], value) {
+		t.Errorf("fetch multi failed, wrong value: expected %v, got %v", value, values[testKey])
+	}
+}
+
+func TestShardedStale(t *testing.T) {
+	c := New().(cachego.StaleCache)
+
+	if err := c.SaveStale(testKey, testValue, 1*time.Nanosecond, 10*time.Second); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, stale, _ := c.FetchStale(testKey); res != testValue || !stale {
+		t.Errorf("fetch stale fail: expected a stale %s, got %s (stale %v)", testValue, res, stale)
+	}
+
+	_ = c.SaveStale(testKey, testValue, 0, 1*time.Nanosecond)
+
+	if _, _, err := c.FetchStale(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+}
+
+func TestShardedSuite(t *testing.T) {
+	cachegotest.RunClockSuite(t, func(_ *testing.T, clock cachego.Clock) cachego.Cache {
+		return New(WithClock(clock), WithShards(4))
+	})
+}
+
+func TestShardedShards(t *testing.T) {
+	c := New(WithShards(4)).(*sharded)
+
+	for i := 0; i < 100; i++ {
+		_ = c.Save(strconv.Itoa(i), testValue, 0)
+	}
+
+	if len(c.shards) != 4 {
+		t.Fatalf("shards failed: expected %d, got %d", 4, len(c.shards))
+	}
+
+	for i, sh := range c.shards {
+		if len(sh.items) == 0 {
+			t.Errorf("shards failed: the shard %d should have keys", i)
+		}
+	}
+
+	if c := New(WithShards(0)).(*sharded); len(c.shards) != defaultShards {
+		t.Errorf("shards failed: expected %d, got %d", defaultShards, len(c.shards))
+	}
+}
+
+func TestShardedFlushConcurrency(t *testing.T) {
+	c := New()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				key := fmt.Sprintf("%d-%d", i, j)
+
+				_ = c.Save(key, testValue, 0)
+				_, _ = c.Fetch(key)
+			}
+		}(i)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 10; j++ {
+				_ = c.Flush()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	_ = c.Flush()
+
+	if values := c.FetchMulti([]string{"0-0", "3-99"}); len(values) != 0 {
+		t.Errorf("flush failed: expected %d, got %d", 0, len(values))
+	}
+}
+
+func TestShardedJanitor(t *testing.T) {
+	clock := cachegotest.NewClock(time.Now())
+	c := New(WithClock(clock), WithJanitor(time.Millisecond))
+
+	_ = c.Save(testKey, testValue, time.Second)
+	clock.Advance(2 * time.Second)
+
+	purged := func() bool {
+		sh := c.(*sharded).shard(testKey)
+
+		sh.RLock()
+		defer sh.RUnlock()
+
+		_, ok := sh.items[testKey]
+		return !ok
+	}
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline) && !purged(); {
+		time.Sleep(time.Millisecond)
+	}
+
+	if !purged() {
+		t.Errorf("janitor failed: the key %s should be purged", testKey)
+	}
+
+	if err := c.(*sharded).Close(); err != nil {
+		t.Errorf("close failed: expected nil, got %v", err)
+	}
+}
+
+// BenchmarkMixed compares the Sharded and SyncMap drivers under concurrent
+// loads with the given percentage of writes
+func BenchmarkMixed(b *testing.B) {
+	drivers := []struct {
+		name    string
+		factory func() cachego.Cache
+	}{
+		{"sharded", func() cachego.Cache { return New() }},
+		{"sync", func() cachego.Cache { return syncmap.New() }},
+	}
+
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+
+	for _, writes := range []int{10, 50, 90} {
+		for _, d := range drivers {
+			b.Run(fmt.Sprintf("writes=%d%%/%s", writes, d.name), func(b *testing.B) {
+				c := d.factory()
+
+				for _, key := range keys {
+					_ = c.Save(key, testValue, 0)
+				}
+
+				b.ResetTimer()
+
+				b.RunParallel(func(pb *testing.PB) {
+					for i := 0; pb.Next(); i++ {
+						key := keys[i%len(keys)]
+
+						if i%100 < writes {
+							_ = c.Save(key, testValue, 0)
+							continue
+						}
+
+						_, _ = c.Fetch(key)
+					}
+				})
+			})
+		}
+	}
+}
@@ -2,8 +2,11 @@
 package sqlite3
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/faabiosr/cachego"
@@ -11,99 +14,323 @@ import (
 
 type (
 	sqlite3 struct {
-		db    *sql.DB
-		table string
+		db       *sql.DB
+		table    string
+		prefix   string
+		clock    cachego.Clock
+		interval time.Duration
+		janitor  *cachego.Janitor
+	}
+
+	// Option configures the Sqlite3 cache driver
+	Option func(*sqlite3)
+
+	// entry is the row of a cached key, the stale time is in Unix nanoseconds
+	entry struct {
+		value   []byte
+		stale   int64
+		version int64
 	}
 )
 
 // New creates an instance of Sqlite3 cache driver
-func New(db *sql.DB, table string) (cachego.Cache, error) {
-	return &sqlite3{db, table}, createTable(db, table)
+func New(db *sql.DB, table string, opts ...Option) (cachego.Cache, error) {
+	s := &sqlite3{db: db, table: table, clock: cachego.SystemClock{}}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	err := createTable(db, table)
+
+	if err == nil && s.interval > 0 {
+		s.janitor = cachego.NewJanitor(s, s.interval)
+	}
+
+	return s, err
+}
+
+// WithClock sets the clock used to compute the expiration of the keys
+func WithClock(clock cachego.Clock) Option {
+	return func(s *sqlite3) {
+		s.clock = clock
+	}
+}
+
+// WithJanitor purges the expired keys in the background every interval,
+// until the cache is closed
+func WithJanitor(interval time.Duration) Option {
+	return func(s *sqlite3) {
+		s.interval = interval
+	}
 }
 
 func createTable(db *sql.DB, table string) error {
 	stmt := `CREATE TABLE IF NOT EXISTS %s (
         key text PRIMARY KEY,
-        value text NOT NULL,
-        lifetime integer NOT NULL
+        value blob NOT NULL,
+        lifetime integer NOT NULL,
+        expires_at integer NOT NULL DEFAULT 0,
+        stale_at integer NOT NULL DEFAULT 0,
+        version integer NOT NULL DEFAULT 0
     );`
 
-	_, err := db.Exec(fmt.Sprintf(stmt, table))
-	return err
-}
+	if _, err := db.Exec(fmt.Sprintf(stmt, table)); err != nil {
+		return err
+	}
 
-// Contains checks if cached key exists in Sqlite3 storage
-func (s *sqlite3) Contains(key string) bool {
-	_, err := s.Fetch(key)
-	return err == nil
+	return migrate(db, table)
 }
 
-// Delete the cached key from Sqlite3 storage
-func (s *sqlite3) Delete(key string) error {
-	tx, err := s.db.Begin()
+// migrate moves the tables created by the previous releases, which kept the
+// expiration in the lifetime column in Unix seconds, to the expires_at
+// nanosecond column, adding the stale_at and version columns
+func migrate(db *sql.DB, table string) error {
+	columns, err := tableColumns(db, table)
 	if err != nil {
 		return err
 	}
 
-	stmt, err := tx.Prepare(fmt.Sprintf(`
-		DELETE FROM %s
-		WHERE key = ?
-	`, s.table))
+	if columns["expires_at"] {
+		return nil
+	}
+
+	stmts := []string{
+		"ALTER TABLE %s ADD COLUMN expires_at integer NOT NULL DEFAULT 0",
+		"ALTER TABLE %s ADD COLUMN stale_at integer NOT NULL DEFAULT 0",
+		"ALTER TABLE %s ADD COLUMN version integer NOT NULL DEFAULT 0",
+		"UPDATE %s SET expires_at = lifetime * 1000000000 WHERE lifetime > 0",
+	}
+
+	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
 
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(fmt.Sprintf(stmt, table)); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, err
+	}
+
 	defer func() {
-		_ = stmt.Close()
+		_ = rows.Close()
 	}()
 
-	if _, err := stmt.Exec(key); err != nil {
+	columns := make(map[string]bool)
+
+	for rows.Next() {
+		var name string
+
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		columns[name] = true
+	}
+
+	return columns, rows.Err()
+}
+
+// exec runs the query with args inside a transaction
+func (s *sqlite3) exec(ctx context.Context, query string, args ...any) error {
+	return s.execMulti(ctx, query, [][]any{args})
+}
+
+// execMulti runs the query once for each args, preparing it once inside a
+// single transaction
+func (s *sqlite3) execMulti(ctx context.Context, query string, args [][]any) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
 		_ = tx.Rollback()
 		return err
 	}
 
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	for _, a := range args {
+		if _, err := stmt.ExecContext(ctx, a...); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
 	return tx.Commit()
 }
 
+// Close stops the janitor of the Sqlite3 storage, the database is not closed
+func (s *sqlite3) Close() error {
+	if s.janitor != nil {
+		s.janitor.Stop()
+	}
+
+	return nil
+}
+
+// Contains checks if cached key exists in Sqlite3 storage
+func (s *sqlite3) Contains(key string) bool {
+	return s.ContainsContext(context.Background(), key)
+}
+
+// ContainsContext checks if cached key exists in Sqlite3 storage
+func (s *sqlite3) ContainsContext(ctx context.Context, key string) bool {
+	_, err := s.FetchContext(ctx, key)
+	return err == nil
+}
+
+// Delete the cached key from Sqlite3 storage
+func (s *sqlite3) Delete(key string) error {
+	return s.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext the cached key from Sqlite3 storage
+func (s *sqlite3) DeleteContext(ctx context.Context, key string) error {
+	return s.deleteMulti(ctx, []string{key})
+}
+
+// DeleteMulti removes multiple cached keys from Sqlite3 storage in a single transaction
+func (s *sqlite3) DeleteMulti(keys []string) error {
+	return s.deleteMulti(context.Background(), keys)
+}
+
+func (s *sqlite3) deleteMulti(ctx context.Context, keys []string) error {
+	args := make([][]any, len(keys))
+	for i, key := range keys {
+		args[i] = []any{s.prefix + key}
+	}
+
+	return s.execMulti(ctx, fmt.Sprintf(`
+		DELETE FROM %s
+		WHERE key = ?
+	`, s.table), args)
+}
+
 // Fetch retrieves the cached value from key of the Sqlite3 storage
 func (s *sqlite3) Fetch(key string) (string, error) {
-	stmt, err := s.db.Prepare(fmt.Sprintf(`
-		SELECT value, lifetime
+	return s.FetchContext(context.Background(), key)
+}
+
+// FetchContext retrieves the cached value from key of the Sqlite3 storage
+func (s *sqlite3) FetchContext(ctx context.Context, key string) (string, error) {
+	e, err := s.read(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	return string(e.value), nil
+}
+
+// FetchBytes retrieves the cached binary value from key of the Sqlite3 storage
+func (s *sqlite3) FetchBytes(key string) ([]byte, error) {
+	e, err := s.read(context.Background(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.value, nil
+}
+
+// FetchStale retrieves the cached value from key of the Sqlite3 storage and
+// whether it is stale
+func (s *sqlite3) FetchStale(key string) (string, bool, error) {
+	e, err := s.read(context.Background(), key)
+	if err != nil {
+		return "", false, err
+	}
+
+	return string(e.value), e.stale > 0 && e.stale <= s.clock.Now().UnixNano(), nil
+}
+
+// FetchWithVersion retrieves the cached value from key of the Sqlite3 storage
+// and its version
+func (s *sqlite3) FetchWithVersion(key string) (string, string, error) {
+	e, err := s.read(context.Background(), key)
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(e.value), strconv.FormatInt(e.version, 10), nil
+}
+
+func (s *sqlite3) read(ctx context.Context, key string) (*entry, error) {
+	stmt, err := s.db.PrepareContext(ctx, fmt.Sprintf(`
+		SELECT value, expires_at, stale_at, version
 		FROM %s WHERE key = ?
 	`, s.table))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	defer func() {
 		_ = stmt.Close()
 	}()
 
-	var value string
-	var lifetime int64
+	e := &entry{}
 
-	if err := stmt.QueryRow(key).Scan(&value, &lifetime); err != nil {
-		return "", err
+	var expiresAt int64
+
+	err = stmt.QueryRowContext(ctx, s.prefix+key).Scan(&e.value, &expiresAt, &e.stale, &e.version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, cachego.ErrCacheMiss
+	}
+
+	if err != nil {
+		return nil, err
 	}
 
-	if lifetime == 0 {
-		return value, nil
+	if expiresAt == 0 {
+		return e, nil
 	}
 
-	if lifetime <= time.Now().Unix() {
-		_ = s.Delete(key)
-		return "", cachego.ErrCacheExpired
+	if expiresAt <= s.clock.Now().UnixNano() {
+		_ = s.DeleteContext(ctx, key)
+		return nil, cachego.ErrCacheExpired
 	}
 
-	return value, nil
+	return e, nil
 }
 
 // FetchMulti retrieves multiple cached value from keys of the Sqlite3 storage
 func (s *sqlite3) FetchMulti(keys []string) map[string]string {
+	return s.FetchMultiContext(context.Background(), keys)
+}
+
+// FetchMultiContext retrieves multiple cached value from keys of the Sqlite3 storage
+func (s *sqlite3) FetchMultiContext(ctx context.Context, keys []string) map[string]string {
 	result := make(map[string]string)
 
 	for _, key := range keys {
-		if value, err := s.Fetch(key); err == nil {
+		if value, err := s.FetchContext(ctx, key); err == nil {
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+// FetchMultiBytes retrieves multiple cached binary value from keys of the Sqlite3 storage
+func (s *sqlite3) FetchMultiBytes(keys []string) map[string][]byte {
+	result := make(map[string][]byte)
+
+	for _, key := range keys {
+		if value, err := s.FetchBytes(key); err == nil {
 			result[key] = value
 		}
 	}
@@ -113,57 +340,252 @@ func (s *sqlite3) FetchMulti(keys []string) map[string]string {
 
 // Flush removes all cached keys of the Sqlite3 storage
 func (s *sqlite3) Flush() error {
-	tx, err := s.db.Begin()
-	if err != nil {
-		return err
-	}
+	return s.FlushContext(context.Background())
+}
 
-	stmt, err := tx.Prepare(fmt.Sprintf("DELETE FROM %s", s.table))
-	if err != nil {
-		return err
+// FlushContext removes all cached keys of the Sqlite3 storage
+func (s *sqlite3) FlushContext(ctx context.Context) error {
+	if s.prefix == "" {
+		return s.exec(ctx, fmt.Sprintf("DELETE FROM %s", s.table))
 	}
 
-	defer func() {
-		_ = stmt.Close()
-	}()
+	return s.exec(ctx, fmt.Sprintf(`
+		DELETE FROM %s
+		WHERE substr(key, 1, length(?)) = ?
+	`, s.table), s.prefix, s.prefix)
+}
 
-	if _, err := stmt.Exec(); err != nil {
-		_ = tx.Rollback()
-		return err
+// Namespace returns a view of the Sqlite3 storage that prefixes all the keys
+// by the prefix and a colon, sharing the table without its janitor
+func (s *sqlite3) Namespace(prefix string) cachego.Cache {
+	return &sqlite3{db: s.db, table: s.table, prefix: s.prefix + prefix + ":", clock: s.clock}
+}
+
+// PurgeExpired removes the expired keys of the Sqlite3 storage
+func (s *sqlite3) PurgeExpired() (int, error) {
+	result, err := s.db.Exec(fmt.Sprintf(`
+		DELETE FROM %s
+		WHERE expires_at > 0 AND expires_at <= ?
+	`, s.table), s.clock.Now().UnixNano())
+	if err != nil {
+		return 0, err
 	}
 
-	return tx.Commit()
+	purged, err := result.RowsAffected()
+	return int(purged), err
 }
 
 // Save a value in Sqlite3 storage by key
 func (s *sqlite3) Save(key string, value string, lifeTime time.Duration) error {
-	duration := int64(0)
+	return s.SaveContext(context.Background(), key, value, lifeTime)
+}
 
-	if lifeTime > 0 {
-		duration = time.Now().Unix() + int64(lifeTime.Seconds())
+// SaveContext a value in Sqlite3 storage by key
+func (s *sqlite3) SaveContext(ctx context.Context, key string, value string, lifeTime time.Duration) error {
+	return s.write(ctx, key, []byte(value), 0, lifeTime)
+}
+
+// SaveBytes a binary value in Sqlite3 storage by key
+func (s *sqlite3) SaveBytes(key string, value []byte, lifeTime time.Duration) error {
+	return s.write(context.Background(), key, value, 0, lifeTime)
+}
+
+// SaveStale a value in Sqlite3 storage by key that becomes stale after the stale time
+func (s *sqlite3) SaveStale(key string, value string, staleTime, lifeTime time.Duration) error {
+	return s.write(context.Background(), key, []byte(value), staleTime, lifeTime)
+}
+
+// SaveMulti multiple values in Sqlite3 storage by their keys in a single transaction
+func (s *sqlite3) SaveMulti(values map[string]string, lifeTime time.Duration) error {
+	args := make([][]any, 0, len(values))
+
+	for key, value := range values {
+		args = append(args, s.row(key, []byte(value), 0, lifeTime))
 	}
 
-	tx, err := s.db.Begin()
+	return s.insert(context.Background(), args)
+}
+
+func (s *sqlite3) write(ctx context.Context, key string, value []byte, staleTime, lifeTime time.Duration) error {
+	return s.insert(ctx, [][]any{s.row(key, value, staleTime, lifeTime)})
+}
+
+// insert writes the rows, replacing the version of the keys by a random one
+func (s *sqlite3) insert(ctx context.Context, rows [][]any) error {
+	return s.execMulti(ctx, fmt.Sprintf(`
+		INSERT OR REPLACE INTO %s (key, value, lifetime, expires_at, stale_at, version)
+		VALUES (?, ?, ?, ?, ?, random())
+	`, s.table), rows)
+}
+
+// SaveIfVersion a value in Sqlite3 storage by key while its version is still
+// the version, in a single statement
+func (s *sqlite3) SaveIfVersion(key, value, version string, lifeTime time.Duration) error {
+	if version == "" {
+		return mismatch(s.insertIfAbsent(key, []byte(value), lifeTime))
+	}
+
+	v, err := strconv.ParseInt(version, 10, 64)
 	if err != nil {
-		return err
+		return cachego.ErrVersionMismatch
 	}
 
-	stmt, err := tx.Prepare(fmt.Sprintf(`
-		INSERT OR REPLACE INTO %s (key, value, lifetime)
-		VALUES (?, ?, ?)
-	`, s.table))
+	row := s.row(key, []byte(value), 0, lifeTime)
+
+	return mismatch(affected(s.db.Exec(fmt.Sprintf(`
+		UPDATE %s
+		SET value = ?, lifetime = ?, expires_at = ?, stale_at = ?, version = random()
+		WHERE key = ? AND version = ? AND (expires_at = 0 OR expires_at > ?)
+	`, s.table), row[1], row[2], row[3], row[4], row[0], v, s.clock.Now().UnixNano())))
+}
+
+// DeleteIfVersion removes the cached key from Sqlite3 storage while its
+// version is still the version, in a single statement
+func (s *sqlite3) DeleteIfVersion(key, version string) error {
+	v, err := strconv.ParseInt(version, 10, 64)
 	if err != nil {
-		return err
+		return cachego.ErrVersionMismatch
 	}
 
-	defer func() {
-		_ = stmt.Close()
-	}()
+	return mismatch(affected(s.db.Exec(fmt.Sprintf(`
+		DELETE FROM %s
+		WHERE key = ? AND version = ? AND (expires_at = 0 OR expires_at > ?)
+	`, s.table), s.prefix+key, v, s.clock.Now().UnixNano())))
+}
 
-	if _, err := stmt.Exec(key, value, duration); err != nil {
-		_ = tx.Rollback()
-		return err
+// SaveIfAbsent a value in Sqlite3 storage by key only when the key is missing
+func (s *sqlite3) SaveIfAbsent(key string, value string, lifeTime time.Duration) (bool, error) {
+	return s.insertIfAbsent(key, []byte(value), lifeTime)
+}
+
+// insertIfAbsent inserts the key when it is missing, the expired key is
+// replaced as a missing one
+func (s *sqlite3) insertIfAbsent(key string, value []byte, lifeTime time.Duration) (bool, error) {
+	row := s.row(key, value, 0, lifeTime)
+
+	return affected(s.db.Exec(fmt.Sprintf(`
+		INSERT INTO %s (key, value, lifetime, expires_at, stale_at, version)
+		VALUES (?, ?, ?, ?, ?, random())
+		ON CONFLICT (key) DO UPDATE SET
+			value = excluded.value,
+			lifetime = excluded.lifetime,
+			expires_at = excluded.expires_at,
+			stale_at = excluded.stale_at,
+			version = excluded.version
+		WHERE expires_at > 0 AND expires_at <= ?
+	`, s.table), append(row, s.clock.Now().UnixNano())...))
+}
+
+// Replace a value in Sqlite3 storage by key only when the key exists
+func (s *sqlite3) Replace(key string, value string, lifeTime time.Duration) (bool, error) {
+	row := s.row(key, []byte(value), 0, lifeTime)
+
+	return affected(s.db.Exec(fmt.Sprintf(`
+		UPDATE %s
+		SET value = ?, lifetime = ?, expires_at = ?, stale_at = ?, version = random()
+		WHERE key = ? AND (expires_at = 0 OR expires_at > ?)
+	`, s.table), row[1], row[2], row[3], row[4], row[0], s.clock.Now().UnixNano()))
+}
+
+// Touch updates the life time of the cached key in Sqlite3 storage
+func (s *sqlite3) Touch(key string, lifeTime time.Duration) error {
+	row := s.row(key, nil, 0, lifeTime)
+
+	ok, err := affected(s.db.Exec(fmt.Sprintf(`
+		UPDATE %s
+		SET lifetime = ?, expires_at = ?
+		WHERE key = ? AND (expires_at = 0 OR expires_at > ?)
+	`, s.table), row[2], row[3], row[0], s.clock.Now().UnixNano()))
+	if err == nil && !ok {
+		return cachego.ErrCacheMiss
 	}
 
-	return tx.Commit()
+	return err
+}
+
+// Incr increments the value of the cached key in Sqlite3 storage in a single
+// statement, keeping its life time
+func (s *sqlite3) Incr(key string, delta int64) (int64, error) {
+	ctx := context.Background()
+
+	for {
+		var value int64
+
+		// the values are integers when they are written back as they are read
+		err := s.db.QueryRowContext(ctx, fmt.Sprintf(`
+			UPDATE %s
+			SET value = CAST(CAST(CAST(value AS text) AS integer) + ? AS blob), version = random()
+			WHERE key = ? AND (expires_at = 0 OR expires_at > ?)
+				AND CAST(CAST(CAST(value AS text) AS integer) AS text) = CAST(value AS text)
+			RETURNING CAST(CAST(value AS text) AS integer)
+		`, s.table), delta, s.prefix+key, s.clock.Now().UnixNano()).Scan(&value)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return value, err
+		}
+
+		ok, err := s.insertIfAbsent(key, []byte(strconv.FormatInt(delta, 10)), 0)
+		if err != nil {
+			return 0, err
+		}
+
+		if ok {
+			return delta, nil
+		}
+
+		// the key was neither incremented nor missing, unless it was
+		// written concurrently
+		if _, err := s.read(ctx, key); err == nil {
+			return 0, cachego.ErrNotInteger
+		}
+	}
+}
+
+// Decr decrements the value of the cached key in Sqlite3 storage, keeping its
+// life time
+func (s *sqlite3) Decr(key string, delta int64) (int64, error) {
+	return s.Incr(key, -delta)
+}
+
+// affected returns whether the conditional write changed a row
+func affected(result sql.Result, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+
+	return n > 0, err
+}
+
+// mismatch returns ErrVersionMismatch when the conditional write changed no row
+func mismatch(ok bool, err error) error {
+	if err == nil && !ok {
+		return cachego.ErrVersionMismatch
+	}
+
+	return err
+}
+
+// row returns the columns of the key
+func (s *sqlite3) row(key string, value []byte, staleTime, lifeTime time.Duration) []any {
+	if value == nil {
+		value = []byte{}
+	}
+
+	now := s.clock.Now()
+	expiresAt, stale := int64(0), int64(0)
+
+	if lifeTime > 0 {
+		expiresAt = now.Add(lifeTime).UnixNano()
+	}
+
+	if staleTime > 0 {
+		stale = now.Add(staleTime).UnixNano()
+	}
+
+	// lifetime keeps the expiration rounded up to Unix seconds for the
+	// previous versions sharing the table
+	lifetime := (expiresAt + int64(time.Second) - 1) / int64(time.Second)
+
+	return []any{s.prefix + key, value, lifetime, expiresAt, stale}
 }
@@ -1,13 +1,20 @@
 package sqlite3
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"testing"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/faabiosr/cachego"
+	"github.com/faabiosr/cachego/cachegotest"
 )
 
 const (
@@ -122,3 +129,339 @@ func TestSqlite3Fail(t *testing.T) {
 		t.Errorf("flush failed: expected an error, got %v", err)
 	}
 }
+
+func TestSqlite3Context(t *testing.T) {
+	db, err := sql.Open("sqlite3", t.TempDir()+testDBPath)
+	if err != nil {
+		t.Skip(err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	cache, err := New(db, testTable)
+	if err != nil {
+		t.Skip(err)
+	}
+
+	c := cache.(cachego.ContextCache)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if err := c.SaveContext(ctx, testKey, testValue, 0); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.FetchContext(ctx, testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	cancel()
+
+	if err := c.SaveContext(ctx, testKey, testValue, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("save failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if _, err := c.FetchContext(ctx, testKey); !errors.Is(err, context.Canceled) {
+		t.Errorf("fetch failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if err := c.DeleteContext(ctx, testKey); !errors.Is(err, context.Canceled) {
+		t.Errorf("delete failed: expected %v, got %v", context.Canceled, err)
+	}
+
+	if err := c.FlushContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("flush failed: expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestSqlite3Bytes(t *testing.T) {
+	db, err := sql.Open("sqlite3", t.TempDir()+testDBPath)
+	if err != nil {
+		t.Skip(err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	cache, err := New(db, testTable)
+	if err != nil {
+		t.Skip(err)
+	}
+
+	c := cache.(cachego.BytesCache)
+	value := []byte{0x00, 0xff, 0xfe, '"', '\\'}
+
+	if err := c.SaveBytes(testKey, value, 10*time.Second); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.FetchBytes(testKey); !bytes.Equal(res, value) {
+		t.Errorf("fetch fail, wrong value: expected %v, got %v", value, res)
+	}
+
+	if _, err := c.FetchBytes("bar"); err == nil {
+		t.Errorf("fetch fail: expected an error, got %v", err)
+	}
+
+	if values := c.FetchMultiBytes([]string{testKey, "bar"}); !bytes.Equal(values[testKey], value) {
+		t.Errorf("fetch multi failed, wrong value: expected %v, got %v", value, values[testKey])
+	}
+}
+
+func TestSqlite3CacheMiss(t *testing.T) {
+	db, err := sql.Open("sqlite3", t.TempDir()+testDBPath)
+	if err != nil {
+		t.Skip(err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	c, err := New(db, testTable)
+	if err != nil {
+		t.Skip(err)
+	}
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+
+	if _, err := c.(cachego.BytesCache).FetchBytes(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+
+	_ = c.Save(testKey, testValue, 1*time.Nanosecond)
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+}
+
+func TestSqlite3Stale(t *testing.T) {
+	db, err := sql.Open("sqlite3", t.TempDir()+testDBPath)
+	if err != nil {
+		t.Skip(err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	cache, err := New(db, testTable)
+	if err != nil {
+		t.Skip(err)
+	}
+
+	c := cache.(cachego.StaleCache)
+
+	if err := c.SaveStale(testKey, testValue, 1*time.Nanosecond, 10*time.Second); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if res, stale, _ := c.FetchStale(testKey); res != testValue || !stale {
+		t.Errorf("fetch stale fail: expected a stale %s, got %s (stale %v)", testValue, res, stale)
+	}
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	_ = c.SaveStale(testKey, testValue, 10*time.Second, 0)
+
+	if _, stale, _ := c.FetchStale(testKey); stale {
+		t.Errorf("fetch stale fail: the key %s should not be stale", testKey)
+	}
+
+	_ = c.SaveStale(testKey, testValue, 0, 1*time.Nanosecond)
+
+	if _, _, err := c.FetchStale(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+}
+
+func TestSqlite3Migration(t *testing.T) {
+	db, err := sql.Open("sqlite3", t.TempDir()+testDBPath)
+	if err != nil {
+		t.Skip(err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	_, err = db.Exec(fmt.Sprintf(`
+		CREATE TABLE %s (key text PRIMARY KEY, value text NOT NULL, lifetime integer NOT NULL);
+		INSERT INTO %s (key, value, lifetime) VALUES ('%s', '%s', 0);
+	`, testTable, testTable, testKey, testValue))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := New(db, testTable)
+	if err != nil {
+		t.Fatalf("new failed: expected nil, got %v", err)
+	}
+
+	if res, stale, _ := c.(cachego.StaleCache).FetchStale(testKey); res != testValue || stale {
+		t.Errorf("fetch stale fail: expected a fresh %s, got %s (stale %v)", testValue, res, stale)
+	}
+
+	cc := c.(cachego.CASCache)
+
+	_, version, err := cc.FetchWithVersion(testKey)
+	if err != nil {
+		t.Errorf("fetch failed: expected nil, got %v", err)
+	}
+
+	if err := cc.SaveIfVersion(testKey, "baz", version, 0); err != nil {
+		t.Errorf("save fail: expected nil for a migrated key, got %v", err)
+	}
+
+	if _, err := New(db, testTable); err != nil {
+		t.Errorf("new failed: expected nil, got %v", err)
+	}
+}
+
+func TestSqlite3Suite(t *testing.T) {
+	cachegotest.RunClockSuite(t, func(t *testing.T, clock cachego.Clock) cachego.Cache {
+		db, err := sql.Open("sqlite3", t.TempDir()+testDBPath)
+		if err != nil {
+			t.Skip(err)
+		}
+
+		t.Cleanup(func() {
+			_ = db.Close()
+		})
+
+		c, err := New(db, testTable, WithClock(clock))
+		if err != nil {
+			t.Skip(err)
+		}
+
+		return c
+	})
+}
+
+func TestSqlite3MigrationSeconds(t *testing.T) {
+	db, err := sql.Open("sqlite3", t.TempDir()+testDBPath)
+	if err != nil {
+		t.Skip(err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	_, err = db.Exec(fmt.Sprintf(`
+		CREATE TABLE %s (key text PRIMARY KEY, value text NOT NULL, lifetime integer NOT NULL);
+		INSERT INTO %s (key, value, lifetime) VALUES ('%s', '%s', 1700000010);
+	`, testTable, testTable, testKey, testValue))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock := cachegotest.NewClock(time.Unix(1700000009, 0))
+
+	cache, err := New(db, testTable, WithClock(clock))
+	if err != nil {
+		t.Fatalf("new failed: expected nil, got %v", err)
+	}
+
+	c := cache.(cachego.StaleCache)
+
+	if res, stale, _ := c.FetchStale(testKey); res != testValue || stale {
+		t.Errorf("fetch stale fail: expected a fresh %s, got %s (stale %v)", testValue, res, stale)
+	}
+
+	clock.Advance(time.Second)
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheExpired) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheExpired, err)
+	}
+
+	_ = c.Save(testKey, testValue, 500*time.Millisecond)
+
+	var lifetime int64
+
+	if err := db.QueryRow(fmt.Sprintf("SELECT lifetime FROM %s", testTable)).Scan(&lifetime); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := clock.Now().Unix() + 1; lifetime != expected {
+		t.Errorf("save failed, wrong lifetime: expected %d, got %d", expected, lifetime)
+	}
+}
+
+func TestSqlite3SubSecond(t *testing.T) {
+	db, err := sql.Open("sqlite3", t.TempDir()+testDBPath)
+	if err != nil {
+		t.Skip(err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	clock := cachegotest.NewClock(time.Now())
+
+	c, err := New(db, testTable, WithClock(clock))
+	if err != nil {
+		t.Skip(err)
+	}
+
+	_ = c.Save(testKey, testValue, 500*time.Millisecond)
+
+	clock.Advance(499 * time.Millisecond)
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	clock.Advance(time.Millisecond)
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheExpired) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheExpired, err)
+	}
+}
+
+func TestSqlite3Janitor(t *testing.T) {
+	db, err := sql.Open("sqlite3", t.TempDir()+testDBPath)
+	if err != nil {
+		t.Skip(err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	clock := cachegotest.NewClock(time.Now())
+
+	c, err := New(db, testTable, WithClock(clock), WithJanitor(time.Millisecond))
+	if err != nil {
+		t.Skip(err)
+	}
+
+	_ = c.Save(testKey, testValue, time.Second)
+	clock.Advance(2 * time.Second)
+
+	count := func() (n int) {
+		_ = db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", testTable)).Scan(&n)
+		return n
+	}
+
+	for deadline := time.Now().Add(time.Second); count() > 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+
+	if n := count(); n != 0 {
+		t.Errorf("janitor failed: expected %d keys, got %d", 0, n)
+	}
+
+	if err := c.(io.Closer).Close(); err != nil {
+		t.Errorf("close failed: expected nil, got %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package cachego
+
+import (
+	"context"
+	"encoding/binary"
+	"strings"
+	"time"
+)
+
+// staleEnvelope prefixes the values saved with a stale time by drivers that
+// do not store it, it is followed by the big-endian stale time in nanoseconds
+const staleEnvelope = "\xffcachego:stale:"
+
+const staleHeader = len(staleEnvelope) + 8
+
+type (
+	// StaleOption configures the StaleCache wrapping a cache
+	StaleOption func(*staleCache)
+
+	staleCache struct {
+		Cache
+		clock Clock
+	}
+)
+
+// NewStaleCache returns a StaleCache for the given cache. When the cache
+// already implements StaleCache it is returned as is, otherwise the stale
+// time is stored in a metadata envelope along with the value, which the
+// fetches of the returned cache remove.
+func NewStaleCache(cache Cache, opts ...StaleOption) StaleCache {
+	if c, ok := cache.(StaleCache); ok {
+		return c
+	}
+
+	c := &staleCache{Cache: cache, clock: SystemClock{}}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// WithStaleClock sets the clock used to compute the stale time of the
+// envelopes, it is ignored by the caches storing the stale time themselves
+func WithStaleClock(clock Clock) StaleOption {
+	return func(c *staleCache) {
+		c.clock = clock
+	}
+}
+
+func openEnvelope(value string) (string, int64) {
+	if len(value) < staleHeader || !strings.HasPrefix(value, staleEnvelope) {
+		return value, 0
+	}
+
+	stale := int64(binary.BigEndian.Uint64([]byte(value[len(staleEnvelope):staleHeader])))
+
+	return value[staleHeader:], stale
+}
+
+func openEnvelopes(values map[string]string) map[string]string {
+	for key, value := range values {
+		values[key], _ = openEnvelope(value)
+	}
+
+	return values
+}
+
+// ContainsContext checks if the cached key exists
+func (c *staleCache) ContainsContext(ctx context.Context, key string) bool {
+	return NewContextCache(c.Cache).ContainsContext(ctx, key)
+}
+
+// DeleteContext removes the cached key
+func (c *staleCache) DeleteContext(ctx context.Context, key string) error {
+	return NewContextCache(c.Cache).DeleteContext(ctx, key)
+}
+
+// Fetch retrieves the cached key value without its envelope
+func (c *staleCache) Fetch(key string) (string, error) {
+	value, _, err := c.FetchStale(key)
+	return value, err
+}
+
+// FetchContext retrieves the cached key value without its envelope
+func (c *staleCache) FetchContext(ctx context.Context, key string) (string, error) {
+	value, err := NewContextCache(c.Cache).FetchContext(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	value, _ = openEnvelope(value)
+
+	return value, nil
+}
+
+// FetchBytes retrieves the cached key binary value without its envelope
+func (c *staleCache) FetchBytes(key string) ([]byte, error) {
+	value, err := NewBytesCache(c.Cache).FetchBytes(key)
+	if err != nil {
+		return nil, err
+	}
+
+	opened, _ := openEnvelope(string(value))
+
+	return []byte(opened), nil
+}
+
+// FetchMulti retrieves multiple cached keys value without their envelope
+func (c *staleCache) FetchMulti(keys []string) map[string]string {
+	return openEnvelopes(c.Cache.FetchMulti(keys))
+}
+
+// FetchMultiContext retrieves multiple cached keys value without their envelope
+func (c *staleCache) FetchMultiContext(ctx context.Context, keys []string) map[string]string {
+	return openEnvelopes(NewContextCache(c.Cache).FetchMultiContext(ctx, keys))
+}
+
+// FetchMultiBytes retrieves multiple cached keys binary value without their envelope
+func (c *staleCache) FetchMultiBytes(keys []string) map[string][]byte {
+	result := NewBytesCache(c.Cache).FetchMultiBytes(keys)
+
+	for key, value := range result {
+		opened, _ := openEnvelope(string(value))
+		result[key] = []byte(opened)
+	}
+
+	return result
+}
+
+// FetchStale retrieves the cached key value and whether it is stale
+func (c *staleCache) FetchStale(key string) (string, bool, error) {
+	value, err := c.Cache.Fetch(key)
+	if err != nil {
+		return "", false, err
+	}
+
+	value, stale := openEnvelope(value)
+
+	return value, stale > 0 && stale <= c.clock.Now().UnixNano(), nil
+}
+
+// FlushContext removes all cached keys
+func (c *staleCache) FlushContext(ctx context.Context) error {
+	return NewContextCache(c.Cache).FlushContext(ctx)
+}
+
+// SaveContext caches a value by key
+func (c *staleCache) SaveContext(ctx context.Context, key string, value string, lifeTime time.Duration) error {
+	return NewContextCache(c.Cache).SaveContext(ctx, key, value, lifeTime)
+}
+
+// SaveBytes caches a binary value by key
+func (c *staleCache) SaveBytes(key string, value []byte, lifeTime time.Duration) error {
+	return NewBytesCache(c.Cache).SaveBytes(key, value, lifeTime)
+}
+
+// SaveStale caches a value by key wrapped in an envelope with its stale time
+func (c *staleCache) SaveStale(key string, value string, staleTime, lifeTime time.Duration) error {
+	if staleTime <= 0 {
+		return c.Save(key, value, lifeTime)
+	}
+
+	data := make([]byte, 0, staleHeader+len(value))
+	data = append(data, staleEnvelope...)
+	data = binary.BigEndian.AppendUint64(data, uint64(c.clock.Now().Add(staleTime).UnixNano()))
+	data = append(data, value...)
+
+	return c.Save(key, string(data), lifeTime)
+}
@@ -0,0 +1,78 @@
+package cachego
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStaleCache(t *testing.T) {
+	cache := mapCache{}
+	c := NewStaleCache(cache)
+
+	if c != NewStaleCache(c) {
+		t.Error("stale cache failed: expected the same instance")
+	}
+
+	if err := c.SaveStale("foo", "bar", 1*time.Nanosecond, 0); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	if !strings.HasPrefix(cache["foo"], staleEnvelope) {
+		t.Errorf("save failed: expected the value wrapped in an envelope, got %q", cache["foo"])
+	}
+
+	if res, stale, _ := c.FetchStale("foo"); res != "bar" || !stale {
+		t.Errorf("fetch stale fail: expected a stale %s, got %s (stale %v)", "bar", res, stale)
+	}
+
+	if res, _ := c.Fetch("foo"); res != "bar" {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", "bar", res)
+	}
+
+	if values := c.FetchMulti([]string{"foo"}); values["foo"] != "bar" {
+		t.Errorf("fetch multi failed, wrong value: expected %s, got %s", "bar", values["foo"])
+	}
+
+	_ = c.SaveStale("foo", "bar", 10*time.Second, 0)
+
+	if _, stale, _ := c.FetchStale("foo"); stale {
+		t.Errorf("fetch stale fail: the key %s should not be stale", "foo")
+	}
+
+	_ = c.SaveStale("foo", "bar", 0, 0)
+
+	if cache["foo"] != "bar" {
+		t.Errorf("save failed: expected the plain value, got %q", cache["foo"])
+	}
+
+	if _, _, err := c.FetchStale("baz"); err != ErrCacheMiss {
+		t.Errorf("fetch failed: expected %v, got %v", ErrCacheMiss, err)
+	}
+}
+
+func TestStaleCacheClock(t *testing.T) {
+	now := time.Now()
+	clock := &fixedClock{now}
+	c := NewStaleCache(mapCache{}, WithStaleClock(clock))
+
+	_ = c.SaveStale("foo", "bar", time.Second, 0)
+
+	if _, stale, _ := c.FetchStale("foo"); stale {
+		t.Errorf("fetch stale fail: the key %s should not be stale", "foo")
+	}
+
+	clock.now = now.Add(time.Second)
+
+	if _, stale, _ := c.FetchStale("foo"); !stale {
+		t.Errorf("fetch stale fail: the key %s should be stale", "foo")
+	}
+}
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c *fixedClock) Now() time.Time {
+	return c.now
+}
@@ -2,33 +2,76 @@
 package sync
 
 import (
-	"errors"
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/faabiosr/cachego"
 )
 
 type (
+	// syncMapItem keeps the expiration and stale time in Unix nanoseconds,
+	// the version is unique to each write
 	syncMapItem struct {
-		data     string
+		data     []byte
 		duration int64
+		stale    int64
+		version  uint64
 	}
 
 	syncMap struct {
-		storage *sync.Map
+		storage  *sync.Map
+		prefix   string
+		clock    cachego.Clock
+		interval time.Duration
+		janitor  *cachego.Janitor
 	}
+
+	// Option configures the SyncMap cache driver
+	Option func(*syncMap)
 )
 
+// versions is the last version given to an item
+var versions atomic.Uint64
+
 // New creates an instance of SyncMap cache driver
-func New() cachego.Cache {
-	return &syncMap{&sync.Map{}}
+func New(opts ...Option) cachego.Cache {
+	sm := &syncMap{storage: &sync.Map{}, clock: cachego.SystemClock{}}
+
+	for _, opt := range opts {
+		opt(sm)
+	}
+
+	if sm.interval > 0 {
+		sm.janitor = cachego.NewJanitor(sm, sm.interval)
+	}
+
+	return sm
+}
+
+// WithClock sets the clock used to compute the expiration of the keys
+func WithClock(clock cachego.Clock) Option {
+	return func(sm *syncMap) {
+		sm.clock = clock
+	}
+}
+
+// WithJanitor purges the expired keys in the background every interval,
+// until the cache is closed
+func WithJanitor(interval time.Duration) Option {
+	return func(sm *syncMap) {
+		sm.interval = interval
+	}
 }
 
 func (sm *syncMap) read(key string) (*syncMapItem, error) {
-	v, ok := sm.storage.Load(key)
+	v, ok := sm.storage.Load(sm.prefix + key)
 	if !ok {
-		return nil, errors.New("key not found")
+		return nil, cachego.ErrCacheMiss
 	}
 
 	item := v.(*syncMapItem)
@@ -37,7 +80,7 @@ func (sm *syncMap) read(key string) (*syncMapItem, error) {
 		return item, nil
 	}
 
-	if item.duration <= time.Now().Unix() {
+	if item.duration <= sm.clock.Now().UnixNano() {
 		_ = sm.Delete(key)
 		return nil, cachego.ErrCacheExpired
 	}
@@ -45,34 +88,105 @@ func (sm *syncMap) read(key string) (*syncMapItem, error) {
 	return item, nil
 }
 
+// Close stops the janitor of the SyncMap storage
+func (sm *syncMap) Close() error {
+	if sm.janitor != nil {
+		sm.janitor.Stop()
+	}
+
+	return nil
+}
+
 // Contains checks if cached key exists in SyncMap storage
 func (sm *syncMap) Contains(key string) bool {
-	_, err := sm.Fetch(key)
+	return sm.ContainsContext(context.Background(), key)
+}
+
+// ContainsContext checks if cached key exists in SyncMap storage
+func (sm *syncMap) ContainsContext(ctx context.Context, key string) bool {
+	_, err := sm.FetchContext(ctx, key)
 	return err == nil
 }
 
 // Delete the cached key from SyncMap storage
 func (sm *syncMap) Delete(key string) error {
-	sm.storage.Delete(key)
+	return sm.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext the cached key from SyncMap storage
+func (sm *syncMap) DeleteContext(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	sm.storage.Delete(sm.prefix + key)
 	return nil
 }
 
 // Fetch retrieves the cached value from key of the SyncMap storage
 func (sm *syncMap) Fetch(key string) (string, error) {
+	return sm.FetchContext(context.Background(), key)
+}
+
+// FetchContext retrieves the cached value from key of the SyncMap storage
+func (sm *syncMap) FetchContext(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
 	item, err := sm.read(key)
 	if err != nil {
 		return "", err
 	}
 
-	return item.data, nil
+	return string(item.data), nil
+}
+
+// FetchBytes retrieves the cached binary value from key of the SyncMap storage
+func (sm *syncMap) FetchBytes(key string) ([]byte, error) {
+	item, err := sm.read(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.Clone(item.data), nil
+}
+
+// FetchStale retrieves the cached value from key of the SyncMap storage and
+// whether it is stale
+func (sm *syncMap) FetchStale(key string) (string, bool, error) {
+	item, err := sm.read(key)
+	if err != nil {
+		return "", false, err
+	}
+
+	return string(item.data), item.stale > 0 && item.stale <= sm.clock.Now().UnixNano(), nil
 }
 
 // FetchMulti retrieves multiple cached value from keys of the SyncMap storage
 func (sm *syncMap) FetchMulti(keys []string) map[string]string {
+	return sm.FetchMultiContext(context.Background(), keys)
+}
+
+// FetchMultiContext retrieves multiple cached value from keys of the SyncMap storage
+func (sm *syncMap) FetchMultiContext(ctx context.Context, keys []string) map[string]string {
 	result := make(map[string]string)
 
 	for _, key := range keys {
-		if value, err := sm.Fetch(key); err == nil {
+		if value, err := sm.FetchContext(ctx, key); err == nil {
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+// FetchMultiBytes retrieves multiple cached binary value from keys of the SyncMap storage
+func (sm *syncMap) FetchMultiBytes(keys []string) map[string][]byte {
+	result := make(map[string][]byte)
+
+	for _, key := range keys {
+		if value, err := sm.FetchBytes(key); err == nil {
 			result[key] = value
 		}
 	}
@@ -82,18 +196,257 @@ func (sm *syncMap) FetchMulti(keys []string) map[string]string {
 
 // Flush removes all cached keys of the SyncMap storage
 func (sm *syncMap) Flush() error {
-	sm.storage = &sync.Map{}
+	return sm.FlushContext(context.Background())
+}
+
+// FlushContext removes all cached keys of the SyncMap storage
+func (sm *syncMap) FlushContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	sm.storage.Range(func(key, _ any) bool {
+		if strings.HasPrefix(key.(string), sm.prefix) {
+			sm.storage.Delete(key)
+		}
+
+		return true
+	})
+
 	return nil
 }
 
+// Namespace returns a view of the SyncMap storage that prefixes all the keys
+// by the prefix and a colon, sharing the storage without its janitor
+func (sm *syncMap) Namespace(prefix string) cachego.Cache {
+	return &syncMap{storage: sm.storage, prefix: sm.prefix + prefix + ":", clock: sm.clock}
+}
+
+// PurgeExpired removes the expired keys of the SyncMap storage
+func (sm *syncMap) PurgeExpired() (int, error) {
+	now := sm.clock.Now().UnixNano()
+	purged := 0
+
+	sm.storage.Range(func(key, value any) bool {
+		item := value.(*syncMapItem)
+
+		if item.duration > 0 && item.duration <= now && sm.storage.CompareAndDelete(key, value) {
+			purged++
+		}
+
+		return true
+	})
+
+	return purged, nil
+}
+
 // Save a value in SyncMap storage by key
 func (sm *syncMap) Save(key string, value string, lifeTime time.Duration) error {
-	duration := int64(0)
+	return sm.SaveContext(context.Background(), key, value, lifeTime)
+}
+
+// SaveContext a value in SyncMap storage by key
+func (sm *syncMap) SaveContext(ctx context.Context, key string, value string, lifeTime time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	sm.store(key, []byte(value), 0, lifeTime)
+	return nil
+}
+
+// SaveBytes a binary value in SyncMap storage by key
+func (sm *syncMap) SaveBytes(key string, value []byte, lifeTime time.Duration) error {
+	sm.store(key, bytes.Clone(value), 0, lifeTime)
+	return nil
+}
+
+// SaveStale a value in SyncMap storage by key that becomes stale after the stale time
+func (sm *syncMap) SaveStale(key string, value string, staleTime, lifeTime time.Duration) error {
+	sm.store(key, []byte(value), staleTime, lifeTime)
+	return nil
+}
+
+func (sm *syncMap) store(key string, data []byte, staleTime, lifeTime time.Duration) {
+	sm.storage.Store(sm.prefix+key, sm.item(data, staleTime, lifeTime))
+}
+
+// item returns the item of the data expiring after the life time
+func (sm *syncMap) item(data []byte, staleTime, lifeTime time.Duration) *syncMapItem {
+	now := sm.clock.Now()
+	item := &syncMapItem{data: data, version: versions.Add(1)}
 
 	if lifeTime > 0 {
-		duration = time.Now().Unix() + int64(lifeTime.Seconds())
+		item.duration = now.Add(lifeTime).UnixNano()
+	}
+
+	if staleTime > 0 {
+		item.stale = now.Add(staleTime).UnixNano()
+	}
+
+	return item
+}
+
+// SaveIfAbsent a value in SyncMap storage by key only when the key is missing
+func (sm *syncMap) SaveIfAbsent(key string, value string, lifeTime time.Duration) (bool, error) {
+	saved := false
+
+	err := sm.update(key, func(current *syncMapItem) (*syncMapItem, error) {
+		if saved = current == nil; !saved {
+			return nil, nil
+		}
+
+		return sm.item([]byte(value), 0, lifeTime), nil
+	})
+
+	return saved, err
+}
+
+// Replace a value in SyncMap storage by key only when the key exists
+func (sm *syncMap) Replace(key string, value string, lifeTime time.Duration) (bool, error) {
+	saved := false
+
+	err := sm.update(key, func(current *syncMapItem) (*syncMapItem, error) {
+		if saved = current != nil; !saved {
+			return nil, nil
+		}
+
+		return sm.item([]byte(value), 0, lifeTime), nil
+	})
+
+	return saved, err
+}
+
+// Touch updates the life time of the cached key in SyncMap storage
+func (sm *syncMap) Touch(key string, lifeTime time.Duration) error {
+	return sm.update(key, func(current *syncMapItem) (*syncMapItem, error) {
+		if current == nil {
+			return nil, cachego.ErrCacheMiss
+		}
+
+		item := sm.item(current.data, 0, lifeTime)
+		item.stale = current.stale
+
+		return item, nil
+	})
+}
+
+// Incr increments the value of the cached key in SyncMap storage, keeping
+// its life time
+func (sm *syncMap) Incr(key string, delta int64) (int64, error) {
+	var value int64
+
+	err := sm.update(key, func(current *syncMapItem) (*syncMapItem, error) {
+		item := sm.item(nil, 0, 0)
+
+		if current != nil {
+			n, err := strconv.ParseInt(string(current.data), 10, 64)
+			if err != nil {
+				return nil, cachego.ErrNotInteger
+			}
+
+			item.duration, item.stale, value = current.duration, current.stale, n
+		}
+
+		value += delta
+		item.data = []byte(strconv.FormatInt(value, 10))
+
+		return item, nil
+	})
+
+	return value, err
+}
+
+// Decr decrements the value of the cached key in SyncMap storage, keeping
+// its life time
+func (sm *syncMap) Decr(key string, delta int64) (int64, error) {
+	return sm.Incr(key, -delta)
+}
+
+// FetchWithVersion retrieves the cached value from key of the SyncMap storage
+// and its version
+func (sm *syncMap) FetchWithVersion(key string) (string, string, error) {
+	item, err := sm.read(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(item.data), strconv.FormatUint(item.version, 10), nil
+}
+
+// SaveIfVersion a value in SyncMap storage by key while its version is still
+// the version
+func (sm *syncMap) SaveIfVersion(key, value, version string, lifeTime time.Duration) error {
+	return sm.update(key, func(current *syncMapItem) (*syncMapItem, error) {
+		if itemVersion(current) != version {
+			return nil, cachego.ErrVersionMismatch
+		}
+
+		return sm.item([]byte(value), 0, lifeTime), nil
+	})
+}
+
+// DeleteIfVersion removes the cached key from SyncMap storage while its
+// version is still the version
+func (sm *syncMap) DeleteIfVersion(key, version string) error {
+	v, ok := sm.storage.Load(sm.prefix + key)
+	if !ok || version == "" {
+		return cachego.ErrVersionMismatch
+	}
+
+	item := v.(*syncMapItem)
+	expired := item.duration > 0 && item.duration <= sm.clock.Now().UnixNano()
+
+	if expired || itemVersion(item) != version || !sm.storage.CompareAndDelete(sm.prefix+key, v) {
+		return cachego.ErrVersionMismatch
 	}
 
-	sm.storage.Store(key, &syncMapItem{value, duration})
 	return nil
 }
+
+// itemVersion returns the version of the item, empty for a missing one
+func itemVersion(item *syncMapItem) string {
+	if item == nil {
+		return ""
+	}
+
+	return strconv.FormatUint(item.version, 10)
+}
+
+// update replaces the item of the key by the one returned by fn, which gets
+// nil for a missing or expired key and leaves the key as it is by returning
+// nil, fn is called again when the key changes concurrently
+func (sm *syncMap) update(key string, fn func(*syncMapItem) (*syncMapItem, error)) error {
+	name := sm.prefix + key
+
+	for {
+		v, loaded := sm.storage.Load(name)
+
+		var current *syncMapItem
+
+		if loaded {
+			current = v.(*syncMapItem)
+
+			if current.duration > 0 && current.duration <= sm.clock.Now().UnixNano() {
+				current = nil
+			}
+		}
+
+		item, err := fn(current)
+		if err != nil || item == nil {
+			return err
+		}
+
+		if !loaded {
+			if _, loaded = sm.storage.LoadOrStore(name, item); !loaded {
+				return nil
+			}
+
+			continue
+		}
+
+		if sm.storage.CompareAndSwap(name, v, item) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,256 @@
+// Package tags provides a cache wrapper that invalidates the keys by their tags.
+//
+// Each tag has a version stored in the same cache, the tagged values are saved
+// along with the versions of their tags and are reported as missing once any
+// of the versions changes, so invalidating a tag is a single write no matter
+// how many keys carry it.
+package tags
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/faabiosr/cachego"
+)
+
+// Cache wraps a cache adding the tagged values, the values saved without tags
+// are kept as they are.
+type Cache struct {
+	cachego.Cache
+}
+
+const (
+	// envelope prefixes the tagged values, it is followed by the number of
+	// tags and each tag with its version, all of them length-prefixed
+	envelope = "\xffcachego:tags:"
+
+	// tagPrefix prefixes the keys storing the version of the tags
+	tagPrefix = "cachego:tag:"
+
+	versionSize = 8
+)
+
+// New creates an instance of tags Cache
+func New(cache cachego.Cache) *Cache {
+	return &Cache{cache}
+}
+
+// Contains checks if the cached key exists and its tags are valid
+func (c *Cache) Contains(key string) bool {
+	_, err := c.Fetch(key)
+	return err == nil
+}
+
+// Fetch retrieves the cached key value, a value with an invalidated tag
+// returns cachego.ErrCacheMiss
+func (c *Cache) Fetch(key string) (string, error) {
+	value, err := c.Cache.Fetch(key)
+	if err != nil {
+		return "", err
+	}
+
+	value, tags, err := open(value)
+	if err != nil {
+		return "", err
+	}
+
+	if !valid(tags, c.versions(tagNames(tags))) {
+		return "", cachego.ErrCacheMiss
+	}
+
+	return value, nil
+}
+
+// FetchMulti retrieves multiple cached keys value, leaving out the values
+// with an invalidated tag
+func (c *Cache) FetchMulti(keys []string) map[string]string {
+	result := c.Cache.FetchMulti(keys)
+	opened := make(map[string]map[string]string, len(result))
+
+	var names []string
+
+	for key, value := range result {
+		value, tags, err := open(value)
+		if err != nil {
+			delete(result, key)
+			continue
+		}
+
+		result[key], opened[key] = value, tags
+		names = append(names, tagNames(tags)...)
+	}
+
+	versions := c.versions(names)
+
+	for key, tags := range opened {
+		if !valid(tags, versions) {
+			delete(result, key)
+		}
+	}
+
+	return result
+}
+
+// InvalidateTags invalidates all the cached keys carrying any of the tags
+func (c *Cache) InvalidateTags(tags ...string) error {
+	for _, tag := range tags {
+		version, err := newVersion()
+		if err != nil {
+			return err
+		}
+
+		if err := c.Cache.Save(tagPrefix+tag, version, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SaveWithTags caches a value by key carrying the tags, a tag invalidated
+// while the value is saved makes it reported as missing
+func (c *Cache) SaveWithTags(key string, value string, lifeTime time.Duration, tags ...string) error {
+	if len(tags) == 0 {
+		return c.Save(key, value, lifeTime)
+	}
+
+	versions := c.versions(tags)
+
+	for _, tag := range tags {
+		if _, ok := versions[tag]; ok {
+			continue
+		}
+
+		version, err := newVersion()
+		if err != nil {
+			return err
+		}
+
+		if err := c.Cache.Save(tagPrefix+tag, version, 0); err != nil {
+			return err
+		}
+
+		versions[tag] = version
+	}
+
+	return c.Save(key, seal(value, tags, versions), lifeTime)
+}
+
+// versions retrieves the current version of the tags
+func (c *Cache) versions(tags []string) map[string]string {
+	if len(tags) == 0 {
+		return map[string]string{}
+	}
+
+	keys := make([]string, len(tags))
+	for i, tag := range tags {
+		keys[i] = tagPrefix + tag
+	}
+
+	result := make(map[string]string, len(tags))
+
+	for key, version := range c.Cache.FetchMulti(keys) {
+		result[strings.TrimPrefix(key, tagPrefix)] = version
+	}
+
+	return result
+}
+
+// valid checks if the tags versions of a value are the current ones, a tag
+// without version was invalidated or evicted
+func valid(tags, versions map[string]string) bool {
+	for tag, version := range tags {
+		if current, ok := versions[tag]; !ok || current != version {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newVersion() (string, error) {
+	b := make([]byte, versionSize)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func tagNames(tags map[string]string) []string {
+	names := make([]string, 0, len(tags))
+
+	for tag := range tags {
+		names = append(names, tag)
+	}
+
+	return names
+}
+
+// seal wraps the value in an envelope with the versions of its tags
+func seal(value string, tags []string, versions map[string]string) string {
+	data := []byte(envelope)
+	data = binary.AppendUvarint(data, uint64(len(tags)))
+
+	for _, tag := range tags {
+		data = appendString(data, tag)
+		data = appendString(data, versions[tag])
+	}
+
+	return string(append(data, value...))
+}
+
+// open unwraps the value from its envelope, returning the versions of its
+// tags, the values saved without tags are returned as they are
+func open(value string) (string, map[string]string, error) {
+	if !strings.HasPrefix(value, envelope) {
+		return value, nil, nil
+	}
+
+	data := []byte(value[len(envelope):])
+
+	n, size := binary.Uvarint(data)
+	if size <= 0 {
+		return "", nil, cachego.ErrDecode
+	}
+
+	data = data[size:]
+	tags := make(map[string]string)
+
+	for i := uint64(0); i < n; i++ {
+		var tag, version string
+		var ok bool
+
+		if tag, data, ok = readString(data); !ok {
+			return "", nil, cachego.ErrDecode
+		}
+
+		if version, data, ok = readString(data); !ok {
+			return "", nil, cachego.ErrDecode
+		}
+
+		tags[tag] = version
+	}
+
+	return string(data), tags, nil
+}
+
+func appendString(data []byte, s string) []byte {
+	data = binary.AppendUvarint(data, uint64(len(s)))
+	return append(data, s...)
+}
+
+func readString(data []byte) (string, []byte, bool) {
+	n, size := binary.Uvarint(data)
+	if size <= 0 || uint64(len(data)-size) < n {
+		return "", nil, false
+	}
+
+	end := size + int(n)
+
+	return string(data[size:end]), data[end:], true
+}
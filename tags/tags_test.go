@@ -0,0 +1,137 @@
+package tags
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/faabiosr/cachego"
+	"github.com/faabiosr/cachego/memory"
+)
+
+const (
+	testKey   = "foo"
+	testValue = "bar"
+)
+
+func TestTags(t *testing.T) {
+	c := New(memory.New())
+
+	if err := c.SaveWithTags(testKey, testValue, 0, "user:42", "tenant:7"); err != nil {
+		t.Errorf("save fail: expected nil, got %v", err)
+	}
+
+	_ = c.SaveWithTags("baz", testValue, 0, "tenant:7")
+	_ = c.SaveWithTags("qux", testValue, 0, "user:43")
+	_ = c.SaveWithTags("untagged", testValue, 0)
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+
+	if !c.Contains(testKey) {
+		t.Errorf("contains failed: the key %s should be exist", testKey)
+	}
+
+	if err := c.InvalidateTags("user:42"); err != nil {
+		t.Errorf("invalidate failed: expected nil, got %v", err)
+	}
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+
+	if c.Contains(testKey) {
+		t.Errorf("contains failed: the key %s should not be exist", testKey)
+	}
+
+	values := c.FetchMulti([]string{testKey, "baz", "qux", "untagged"})
+	if len(values) != 3 || values["baz"] != testValue || values["untagged"] != testValue {
+		t.Errorf("fetch multi failed: expected %d, got %v", 3, values)
+	}
+
+	_ = c.InvalidateTags("tenant:7", "user:43")
+
+	if values := c.FetchMulti([]string{testKey, "baz", "qux", "untagged"}); len(values) != 1 {
+		t.Errorf("fetch multi failed: expected %d, got %v", 1, values)
+	}
+
+	_ = c.SaveWithTags(testKey, testValue, 0, "user:42")
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+}
+
+func TestTagsEvicted(t *testing.T) {
+	cache := memory.New()
+	c := New(cache)
+
+	_ = c.SaveWithTags(testKey, testValue, 0, "user:42")
+	_ = cache.Delete(tagPrefix + "user:42")
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrCacheMiss) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrCacheMiss, err)
+	}
+}
+
+func TestTagsDecode(t *testing.T) {
+	cache := memory.New()
+	c := New(cache)
+
+	_ = cache.Save(testKey, envelope+"\x01\x05user", 0)
+
+	if _, err := c.Fetch(testKey); !errors.Is(err, cachego.ErrDecode) {
+		t.Errorf("fetch failed: expected %v, got %v", cachego.ErrDecode, err)
+	}
+
+	if values := c.FetchMulti([]string{testKey}); len(values) != 0 {
+		t.Errorf("fetch multi failed: expected %d, got %d", 0, len(values))
+	}
+}
+
+func TestTagsConcurrency(t *testing.T) {
+	c := New(memory.New())
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				_ = c.SaveWithTags(fmt.Sprintf("%d-%d", i, j), testValue, 0, "tenant:7")
+			}
+		}(i)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 10; j++ {
+				_ = c.InvalidateTags("tenant:7")
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	_ = c.InvalidateTags("tenant:7")
+
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 100; j++ {
+			if key := fmt.Sprintf("%d-%d", i, j); c.Contains(key) {
+				t.Fatalf("invalidate failed: the key %s should not be exist", key)
+			}
+		}
+	}
+
+	_ = c.SaveWithTags(testKey, testValue, time.Minute, "tenant:7")
+
+	if res, _ := c.Fetch(testKey); res != testValue {
+		t.Errorf("fetch fail, wrong value: expected %s, got %s", testValue, res)
+	}
+}
@@ -0,0 +1,62 @@
+package cachego
+
+import (
+	"fmt"
+	"time"
+)
+
+// Typed wraps a cache storing values of type T, the values are converted
+// from and to their cached representation by the codec.
+type Typed[T any] struct {
+	cache BytesCache
+	codec Codec
+}
+
+// NewTyped creates an instance of Typed cache for the values of type T
+func NewTyped[T any](cache Cache, codec Codec) *Typed[T] {
+	return &Typed[T]{NewBytesCache(cache), codec}
+}
+
+// Get retrieves the decoded value of the cached key, a value that can not be
+// decoded returns an error wrapping ErrDecode
+func (t *Typed[T]) Get(key string) (T, error) {
+	var value T
+
+	data, err := t.cache.FetchBytes(key)
+	if err != nil {
+		return value, err
+	}
+
+	if err := t.codec.Unmarshal(data, &value); err != nil {
+		return value, fmt.Errorf("%w: %w", ErrDecode, err)
+	}
+
+	return value, nil
+}
+
+// GetMulti retrieves multiple decoded values of the cached keys, the values
+// that can not be decoded are left out as the missing ones
+func (t *Typed[T]) GetMulti(keys []string) map[string]T {
+	result := make(map[string]T)
+
+	for key, data := range t.cache.FetchMultiBytes(keys) {
+		var value T
+
+		if err := t.codec.Unmarshal(data, &value); err == nil {
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+// Set encodes and caches the value by key, a value that can not be encoded
+// returns an error wrapping ErrEncode
+func (t *Typed[T]) Set(key string, value T, lifeTime time.Duration) error {
+	data, err := t.codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrEncode, err)
+	}
+
+	return t.cache.SaveBytes(key, data, lifeTime)
+}
@@ -0,0 +1,45 @@
+package cachego
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTyped(t *testing.T) {
+	cache := mapCache{}
+	c := NewTyped[codecValue](cache, JSONCodec{})
+	expect := codecValue{"foo", 2}
+
+	if err := c.Set("foo", expect, 0); err != nil {
+		t.Errorf("set fail: expected nil, got %v", err)
+	}
+
+	if res, _ := c.Get("foo"); res != expect {
+		t.Errorf("get fail, wrong value: expected %v, got %v", expect, res)
+	}
+
+	if _, err := c.Get("bar"); err == nil {
+		t.Errorf("get fail: expected an error, got %v", err)
+	}
+
+	_ = cache.Save("bar", "{", 0)
+
+	if _, err := c.Get("bar"); !errors.Is(err, ErrDecode) {
+		t.Errorf("get fail: expected %v, got %v", ErrDecode, err)
+	}
+
+	values := c.GetMulti([]string{"foo", "bar", "baz"})
+	if len(values) != 1 {
+		t.Errorf("get multi failed: expected %d, got %d", 1, len(values))
+	}
+
+	if values["foo"] != expect {
+		t.Errorf("get multi failed, wrong value: expected %v, got %v", expect, values["foo"])
+	}
+
+	invalid := NewTyped[chan int](cache, JSONCodec{})
+
+	if err := invalid.Set("foo", make(chan int), 0); !errors.Is(err, ErrEncode) {
+		t.Errorf("set fail: expected %v, got %v", ErrEncode, err)
+	}
+}